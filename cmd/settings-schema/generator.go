@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonschema"
+)
+
+// generator builds the JSON Schema of the types declared inside of a Go
+// package.
+type generator struct {
+	// types declared inside of the package, indexed by name
+	types map[string]*ast.TypeSpec
+	// docs of the types, indexed by name
+	docs map[string]string
+	// strict forbids the keys not matching any field of the structs
+	strict bool
+	// visiting holds the types being generated, used to stop recursion
+	visiting map[string]bool
+}
+
+func newGenerator(files []*ast.File, strict bool) *generator {
+	g := &generator{
+		types:    map[string]*ast.TypeSpec{},
+		docs:     map[string]string{},
+		strict:   strict,
+		visiting: map[string]bool{},
+	}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, isTypeSpec := spec.(*ast.TypeSpec)
+				if !isTypeSpec {
+					continue
+				}
+				g.types[typeSpec.Name.Name] = typeSpec
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				g.docs[typeSpec.Name.Name] = docText(doc)
+			}
+		}
+	}
+	return g
+}
+
+// generate returns the schema of the named type.
+func (g *generator) generate(typeName string) (*jsonschema.Schema, error) {
+	if _, found := g.types[typeName]; !found {
+		return nil, fmt.Errorf("type %s not found", typeName)
+	}
+
+	schema, err := g.schemaForNamedType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = jsonschema.Draft
+	schema.Title = typeName
+	return schema, nil
+}
+
+func (g *generator) schemaForNamedType(name string) (*jsonschema.Schema, error) {
+	// recursive types cannot be described without references, any value
+	// is accepted by the nested occurrences
+	if g.visiting[name] {
+		return &jsonschema.Schema{}, nil
+	}
+	g.visiting[name] = true
+	defer delete(g.visiting, name)
+
+	schema, err := g.schemaFor(g.types[name].Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if schema.Description == "" {
+		schema.Description = g.docs[name]
+	}
+	return schema, nil
+}
+
+func (g *generator) schemaFor(expr ast.Expr) (*jsonschema.Schema, error) {
+	switch typed := expr.(type) {
+	case *ast.Ident:
+		return g.schemaForIdent(typed.Name)
+	case *ast.StarExpr:
+		// nil pointers are serialized as null
+		schema, err := g.schemaFor(typed.X)
+		if err != nil {
+			return nil, err
+		}
+		schema.Nullable = schema.Type != ""
+		return schema, nil
+	case *ast.ArrayType:
+		if ident, ok := typed.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			// serialized by encoding/json as base64 strings
+			return &jsonschema.Schema{Type: jsonschema.TypeString}, nil
+		}
+		items, err := g.schemaFor(typed.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonschema.Schema{Type: jsonschema.TypeArray, Items: items}, nil
+	case *ast.MapType:
+		values, err := g.schemaFor(typed.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonschema.Schema{Type: jsonschema.TypeObject, AdditionalProperties: values}, nil
+	case *ast.StructType:
+		return g.schemaForStruct(typed)
+	case *ast.SelectorExpr:
+		return schemaForImportedType(typed), nil
+	case *ast.InterfaceType:
+		return &jsonschema.Schema{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type expression %T", expr)
+	}
+}
+
+func (g *generator) schemaForIdent(name string) (*jsonschema.Schema, error) {
+	switch name {
+	case "string":
+		return &jsonschema.Schema{Type: jsonschema.TypeString}, nil
+	case "bool":
+		return &jsonschema.Schema{Type: jsonschema.TypeBoolean}, nil
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+		return &jsonschema.Schema{Type: jsonschema.TypeInteger}, nil
+	case "float32", "float64":
+		return &jsonschema.Schema{Type: jsonschema.TypeNumber}, nil
+	case "any":
+		return &jsonschema.Schema{}, nil
+	}
+
+	if _, found := g.types[name]; found {
+		return g.schemaForNamedType(name)
+	}
+	return nil, fmt.Errorf("unsupported type %s", name)
+}
+
+// schemaForImportedType describes the types declared by other packages.
+// Only a few well known types are supported, any value is accepted for the
+// other ones.
+func schemaForImportedType(selector *ast.SelectorExpr) *jsonschema.Schema {
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return &jsonschema.Schema{}
+	}
+	switch pkg.Name + "." + selector.Sel.Name {
+	case "time.Time":
+		return &jsonschema.Schema{Type: jsonschema.TypeString}
+	case "time.Duration":
+		// serialized by encoding/json as nanoseconds
+		return &jsonschema.Schema{Type: jsonschema.TypeInteger}
+	default:
+		return &jsonschema.Schema{}
+	}
+}
+
+func (g *generator) schemaForStruct(structType *ast.StructType) (*jsonschema.Schema, error) {
+	schema := &jsonschema.Schema{
+		Type:       jsonschema.TypeObject,
+		Properties: map[string]*jsonschema.Schema{},
+	}
+	if g.strict {
+		schema.AdditionalProperties = jsonschema.False()
+	}
+
+	for _, field := range structType.Fields.List {
+		if err := g.addField(schema, field); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+// addField adds the properties described by the struct field to the
+// schema.
+func (g *generator) addField(schema *jsonschema.Schema, field *ast.Field) error {
+	tags := parseTags(field.Tag)
+	jsonName, _, _ := strings.Cut(tags.json, ",")
+	if jsonName == "-" {
+		return nil
+	}
+
+	if len(field.Names) == 0 && jsonName == "" {
+		return g.addEmbeddedField(schema, field)
+	}
+
+	for _, name := range field.Names {
+		if !name.IsExported() {
+			continue
+		}
+		propertyName := jsonName
+		if propertyName == "" {
+			propertyName = name.Name
+		}
+
+		property, err := g.schemaFor(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name.Name, err)
+		}
+		if description := docText(field.Doc); description != "" {
+			property.Description = description
+		} else if comment := docText(field.Comment); comment != "" {
+			property.Description = comment
+		}
+
+		required, err := applyTags(property, tags)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name.Name, err)
+		}
+		if required {
+			schema.Required = append(schema.Required, propertyName)
+		}
+		schema.Properties[propertyName] = property
+	}
+	return nil
+}
+
+// addEmbeddedField adds the properties of an embedded struct, which are
+// promoted by encoding/json to the embedding struct.
+func (g *generator) addEmbeddedField(schema *jsonschema.Schema, field *ast.Field) error {
+	typeExpr := field.Type
+	if star, ok := typeExpr.(*ast.StarExpr); ok {
+		typeExpr = star.X
+	}
+	ident, ok := typeExpr.(*ast.Ident)
+	if !ok {
+		return errors.New("embedded fields must be declared inside of the same package")
+	}
+
+	embedded, err := g.schemaForIdent(ident.Name)
+	if err != nil {
+		return err
+	}
+	for name, property := range embedded.Properties {
+		schema.Properties[name] = property
+	}
+	schema.Required = append(schema.Required, embedded.Required...)
+	return nil
+}
+
+type fieldTags struct {
+	json       string
+	jsonschema string
+	defaultTag string
+	hasDefault bool
+}
+
+func parseTags(tag *ast.BasicLit) fieldTags {
+	if tag == nil {
+		return fieldTags{}
+	}
+	raw, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return fieldTags{}
+	}
+	structTag := reflect.StructTag(raw)
+	defaultTag, hasDefault := structTag.Lookup("default")
+	return fieldTags{
+		json:       structTag.Get("json"),
+		jsonschema: structTag.Get("jsonschema"),
+		defaultTag: defaultTag,
+		hasDefault: hasDefault,
+	}
+}
+
+// applyTags applies the `jsonschema` and `default` tags to the schema of a
+// property. It returns whether the property is required.
+//
+// The `jsonschema` tag is a comma separated list of:
+//   - `required`
+//   - `enum=<value>|<value>|...`
+//   - `minimum=<number>`, `maximum=<number>`
+//   - `minLength=<n>`, `maxLength=<n>`, `minItems=<n>`, `maxItems=<n>`
+//   - `pattern=<regular expression>`, which must be the last item because
+//     the expression can contain commas
+func applyTags(property *jsonschema.Schema, tags fieldTags) (bool, error) {
+	required := false
+	remaining := tags.jsonschema
+	for remaining != "" {
+		var item string
+		if strings.HasPrefix(remaining, "pattern=") {
+			item, remaining = remaining, ""
+		} else {
+			item, remaining, _ = strings.Cut(remaining, ",")
+		}
+
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		var err error
+		switch key {
+		case "required":
+			required = true
+		case "enum":
+			for _, option := range strings.Split(value, "|") {
+				property.Enum = append(property.Enum, parseValue(property, option))
+			}
+		case "minimum":
+			property.Minimum, err = parseFloat(value)
+		case "maximum":
+			property.Maximum, err = parseFloat(value)
+		case "minLength":
+			property.MinLength, err = parseInt(value)
+		case "maxLength":
+			property.MaxLength, err = parseInt(value)
+		case "minItems":
+			property.MinItems, err = parseInt(value)
+		case "maxItems":
+			property.MaxItems, err = parseInt(value)
+		case "pattern":
+			property.Pattern = value
+		case "":
+		default:
+			return false, fmt.Errorf("unknown jsonschema tag %q", key)
+		}
+		if err != nil {
+			return false, fmt.Errorf("invalid jsonschema tag %q: %w", item, err)
+		}
+	}
+
+	if tags.hasDefault {
+		property.Default = parseValue(property, tags.defaultTag)
+	}
+	return required, nil
+}
+
+// parseValue parses a value written inside of a tag. Values of string
+// properties don't have to be quoted, the other ones are JSON.
+func parseValue(property *jsonschema.Schema, value string) interface{} {
+	if property.Type == jsonschema.TypeString {
+		return value
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return value
+	}
+	return parsed
+}
+
+func parseFloat(value string) (*float64, error) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func parseInt(value string) (*int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(strings.Fields(doc.Text()), " "))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const settingsSource = `package policy
+
+import "time"
+
+// Settings of the policy.
+type Settings struct {
+	common
+
+	// Mode of the policy.
+	Mode       string            ` + "`" + `json:"mode" jsonschema:"required,enum=enforce|monitor" default:"enforce"` + "`" + `
+	Replicas   int               ` + "`" + `json:"replicas,omitempty" jsonschema:"minimum=1,maximum=10" default:"3"` + "`" + `
+	Registries []Registry        ` + "`" + `json:"registries" jsonschema:"minItems=1"` + "`" + `
+	Labels     map[string]string ` + "`" + `json:"labels"` + "`" + `
+	Timeout    time.Duration     ` + "`" + `json:"timeout"` + "`" + `
+	Limit      *int              ` + "`" + `json:"limit,omitempty"` + "`" + `
+	Ignored    string            ` + "`" + `json:"-"` + "`" + `
+	internal   string
+}
+
+type common struct {
+	Verbose bool ` + "`" + `json:"verbose"` + "`" + ` // Verbose logging.
+}
+
+// Registry is an OCI registry.
+type Registry struct {
+	Host   string    ` + "`" + `json:"host" jsonschema:"required,pattern=^[a-z.]+(:[0-9]{1,5})?$"` + "`" + `
+	Mirror *Registry ` + "`" + `json:"mirror,omitempty"` + "`" + `
+}
+`
+
+func parseSource(t *testing.T, source string) []*ast.File {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "settings.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("cannot parse source: %v", err)
+	}
+	return []*ast.File{file}
+}
+
+func TestGenerate(t *testing.T) {
+	schema, err := newGenerator(parseSource(t, settingsSource), true).generate("Settings")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	serialized, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"Settings",` +
+		`"description":"Settings of the policy.","type":"object","properties":{` +
+		`"labels":{"type":"object","additionalProperties":{"type":"string"}},` +
+		`"limit":{"type":["integer","null"]},` +
+		`"mode":{"description":"Mode of the policy.","type":"string","enum":["enforce","monitor"],"default":"enforce"},` +
+		`"registries":{"type":"array","items":{"description":"Registry is an OCI registry.","type":"object","properties":{` +
+		`"host":{"type":"string","pattern":"^[a-z.]+(:[0-9]{1,5})?$"},` +
+		`"mirror":{}},"required":["host"],"additionalProperties":false},"minItems":1},` +
+		`"replicas":{"type":"integer","default":3,"minimum":1,"maximum":10},` +
+		`"timeout":{"type":"integer"},` +
+		`"verbose":{"description":"Verbose logging.","type":"boolean"}},` +
+		`"required":["mode"],"additionalProperties":false}`
+	if string(serialized) != expected {
+		t.Fatalf("unexpected schema:\n%s", serialized)
+	}
+
+	if err = schema.Validate([]byte(`{"mode": "enforce", "registries": [{"host": "docker.io"}], "limit": null}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = schema.Validate([]byte(`{"mode": "audit", "registries": [{"host": "Docker.io"}]}`))
+	expectedError := `mode: must be one of ["enforce", "monitor"]; ` +
+		`registries[0].host: must match the pattern "^[a-z.]+(:[0-9]{1,5})?$"`
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		source        string
+		typeName      string
+		expectedError string
+	}{
+		"MissingType": {
+			source:        "package policy\n",
+			typeName:      "Settings",
+			expectedError: "type Settings not found",
+		},
+		"UnsupportedType": {
+			source:        "package policy\ntype Settings struct { C chan int }\n",
+			typeName:      "Settings",
+			expectedError: "Settings: field C: unsupported type expression *ast.ChanType",
+		},
+		"UnknownTag": {
+			source:        "package policy\ntype Settings struct { A string `jsonschema:\"format=uri\"` }\n",
+			typeName:      "Settings",
+			expectedError: `Settings: field A: unknown jsonschema tag "format"`,
+		},
+		"InvalidTagValue": {
+			source:        "package policy\ntype Settings struct { A int `jsonschema:\"minimum=one\"` }\n",
+			typeName:      "Settings",
+			expectedError: `Settings: field A: invalid jsonschema tag "minimum=one": strconv.ParseFloat: parsing "one": invalid syntax`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := newGenerator(parseSource(t, testCase.source), false).generate(testCase.typeName)
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "settings.go"), []byte(settingsSource), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "settings_test.go"), []byte("not go"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := filepath.Join(dir, "settings.schema.json")
+	if err := run(dir, "Registry", false, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Registry",
+  "description": "Registry is an OCI registry.",
+  "type": "object",
+  "properties": {
+    "host": {
+      "type": "string",
+      "pattern": "^[a-z.]+(:[0-9]{1,5})?$"
+    },
+    "mirror": {}
+  },
+  "required": [
+    "host"
+  ]
+}
+`
+	if string(data) != expected {
+		t.Fatalf("unexpected schema:\n%s", data)
+	}
+}
@@ -0,0 +1,91 @@
+// This command generates the JSON Schema of the settings of a policy,
+// starting from the Go type used to decode them.
+//
+// Usage:
+//
+//	go run github.com/kubewarden/policy-sdk-go/cmd/settings-schema \
+//		-dir . -type Settings -strict -o settings.schema.json
+//
+// The source code of the package is parsed, the command doesn't need to
+// build the policy. The schema is built using:
+//   - the `json` tags, to find the names of the properties
+//   - the `default` tags, see `sdk.DecodeSettings`
+//   - the `jsonschema` tags, which can hold `required`, `enum=a|b`,
+//     `minimum=1`, `maximum=10`, `minLength=1`, `maxLength=10`,
+//     `minItems=1`, `maxItems=10` and `pattern=^[a-z]+$`
+//   - the doc comments of the types and of the fields, which become the
+//     descriptions of the schemas
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the Go package declaring the settings")
+	typeName := flag.String("type", "Settings", "name of the settings type")
+	strict := flag.Bool("strict", false, "forbid the keys that don't match any field")
+	output := flag.String("o", "", "file to write the schema to, defaults to the standard output")
+	flag.Parse()
+
+	if err := run(*dir, *typeName, *strict, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "settings-schema: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, typeName string, strict bool, output string) error {
+	files, err := parsePackage(dir)
+	if err != nil {
+		return err
+	}
+
+	schema, err := newGenerator(files, strict).generate(typeName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot serialize schema: %w", err)
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	//nolint:gosec,mnd // the schema is not a secret
+	return os.WriteFile(output, data, 0o644)
+}
+
+// parsePackage parses all the non-test Go files of the directory.
+func parsePackage(dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read directory: %w", err)
+	}
+
+	fileSet := token.NewFileSet()
+	files := []*ast.File{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, parseErr := parser.ParseFile(fileSet, filepath.Join(dir, name), nil, parser.ParseComments)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
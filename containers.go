@@ -0,0 +1,137 @@
+package sdk
+
+import (
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+)
+
+// ContainerType tells which list of the PodSpec a container belongs to.
+type ContainerType string
+
+const (
+	// ContainerTypeContainer is used by the items of `containers`.
+	ContainerTypeContainer ContainerType = "container"
+	// ContainerTypeInitContainer is used by the items of `initContainers`.
+	ContainerTypeInitContainer ContainerType = "initContainer"
+	// ContainerTypeEphemeralContainer is used by the items of
+	// `ephemeralContainers`.
+	ContainerTypeEphemeralContainer ContainerType = "ephemeralContainer"
+)
+
+// ContainerRef is a uniform view over the containers, init containers and
+// ephemeral containers of a PodSpec.
+type ContainerRef struct {
+	Name            string
+	Image           string
+	SecurityContext *corev1.SecurityContext
+	Resources       *corev1.ResourceRequirements
+	// Type is the list of the PodSpec the container belongs to
+	Type ContainerType
+	// Index is the position of the container inside of its list
+	Index int
+	// Path of the container relative to the PodSpec, e.g. `containers[0]`.
+	// Use it together with `Workload.PodSpecPath` to get the path of the
+	// container inside of the whole object.
+	Path rawjson.Path
+}
+
+// ForEachContainer invokes `fn` for each container of the PodSpec: first
+// the ones of `containers`, then `initContainers` and finally
+// `ephemeralContainers`. The iteration stops at the first error returned
+// by `fn`, which is then returned.
+func ForEachContainer(podSpec corev1.PodSpec, fn func(ContainerRef) error) error {
+	return walkContainers(&podSpec, false, func(ref *ContainerRef) error {
+		return fn(*ref)
+	})
+}
+
+// MutateEachContainer is like ForEachContainer, but the changes made by
+// `fn` to the Name, Image, SecurityContext and Resources of the
+// ContainerRef are written back to the container of the PodSpec.
+func MutateEachContainer(podSpec *corev1.PodSpec, fn func(*ContainerRef) error) error {
+	return walkContainers(podSpec, true, fn)
+}
+
+func walkContainers(podSpec *corev1.PodSpec, writeBack bool, fn func(*ContainerRef) error) error {
+	if err := walkContainerList(podSpec.Containers, ContainerTypeContainer, "containers", writeBack, fn); err != nil {
+		return err
+	}
+	if err := walkContainerList(
+		podSpec.InitContainers, ContainerTypeInitContainer, "initContainers", writeBack, fn); err != nil {
+		return err
+	}
+
+	for i, container := range podSpec.EphemeralContainers {
+		if container == nil {
+			continue
+		}
+		ref := ContainerRef{
+			Name:            stringValue(container.Name),
+			Image:           container.Image,
+			SecurityContext: container.SecurityContext,
+			Resources:       container.Resources,
+			Type:            ContainerTypeEphemeralContainer,
+			Index:           i,
+			Path:            rawjson.Path{}.Key("ephemeralContainers").Index(i),
+		}
+		if err := fn(&ref); err != nil {
+			return err
+		}
+		if writeBack {
+			container.Name = stringPointer(container.Name, ref.Name)
+			container.Image = ref.Image
+			container.SecurityContext = ref.SecurityContext
+			container.Resources = ref.Resources
+		}
+	}
+	return nil
+}
+
+func walkContainerList(
+	containers []*corev1.Container,
+	containerType ContainerType,
+	key string,
+	writeBack bool,
+	fn func(*ContainerRef) error,
+) error {
+	for i, container := range containers {
+		if container == nil {
+			continue
+		}
+		ref := ContainerRef{
+			Name:            stringValue(container.Name),
+			Image:           container.Image,
+			SecurityContext: container.SecurityContext,
+			Resources:       container.Resources,
+			Type:            containerType,
+			Index:           i,
+			Path:            rawjson.Path{}.Key(key).Index(i),
+		}
+		if err := fn(&ref); err != nil {
+			return err
+		}
+		if writeBack {
+			container.Name = stringPointer(container.Name, ref.Name)
+			container.Image = ref.Image
+			container.SecurityContext = ref.SecurityContext
+			container.Resources = ref.Resources
+		}
+	}
+	return nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// stringPointer returns `current` when it already points to `value`, so
+// that unchanged names keep being `nil`.
+func stringPointer(current *string, value string) *string {
+	if stringValue(current) == value {
+		return current
+	}
+	return &value
+}
@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+func newPodSpecWithAllContainers() corev1.PodSpec {
+	nginx := "nginx"
+	setup := "setup"
+	debug := "debug"
+	return corev1.PodSpec{
+		Containers: []*corev1.Container{
+			{Name: &nginx, Image: "nginx:1.27"},
+			nil,
+		},
+		InitContainers: []*corev1.Container{
+			{Name: &setup, Image: "busybox", SecurityContext: &corev1.SecurityContext{Privileged: true}},
+		},
+		EphemeralContainers: []*corev1.EphemeralContainer{
+			{Name: &debug, Image: "alpine"},
+		},
+	}
+}
+
+func TestForEachContainer(t *testing.T) {
+	visited := []string{}
+	err := ForEachContainer(newPodSpecWithAllContainers(), func(ref ContainerRef) error {
+		visited = append(visited, string(ref.Type)+" "+ref.Name+" "+ref.Image+" "+ref.Path.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []string{
+		"container nginx nginx:1.27 containers[0]",
+		"initContainer setup busybox initContainers[0]",
+		"ephemeralContainer debug alpine ephemeralContainers[0]",
+	}
+	if diff := cmp.Diff(expected, visited); diff != "" {
+		t.Fatalf("Unexpected containers:\n%s", diff)
+	}
+}
+
+func TestForEachContainerStopsOnError(t *testing.T) {
+	expectedErr := errors.New("privileged container")
+	visited := 0
+	err := ForEachContainer(newPodSpecWithAllContainers(), func(ref ContainerRef) error {
+		visited++
+		if ref.SecurityContext != nil && ref.SecurityContext.Privileged {
+			return expectedErr
+		}
+		return nil
+	})
+	if !errors.Is(err, expectedErr) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if visited != 2 {
+		t.Fatalf("Unexpected number of visited containers: %d", visited)
+	}
+}
+
+func TestMutateEachContainer(t *testing.T) {
+	podSpec := newPodSpecWithAllContainers()
+
+	err := MutateEachContainer(&podSpec, func(ref *ContainerRef) error {
+		ref.Image = "registry.example.com/" + ref.Image
+		if ref.SecurityContext == nil {
+			ref.SecurityContext = &corev1.SecurityContext{}
+		}
+		ref.SecurityContext.Privileged = false
+		if ref.Type == ContainerTypeEphemeralContainer {
+			ref.Name = "debugger"
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if podSpec.Containers[0].Image != "registry.example.com/nginx:1.27" || podSpec.Containers[0].SecurityContext == nil {
+		t.Fatalf("Container not mutated: %+v", podSpec.Containers[0])
+	}
+	if podSpec.Containers[1] != nil {
+		t.Fatalf("Nil container mutated: %+v", podSpec.Containers[1])
+	}
+	if podSpec.InitContainers[0].Image != "registry.example.com/busybox" || podSpec.InitContainers[0].SecurityContext.Privileged {
+		t.Fatalf("Init container not mutated: %+v", podSpec.InitContainers[0])
+	}
+	if podSpec.EphemeralContainers[0].Image != "registry.example.com/alpine" || *podSpec.EphemeralContainers[0].Name != "debugger" {
+		t.Fatalf("Ephemeral container not mutated: %+v", podSpec.EphemeralContainers[0])
+	}
+}
+
+func TestForEachContainerDoesNotMutate(t *testing.T) {
+	podSpec := newPodSpecWithAllContainers()
+
+	err := ForEachContainer(podSpec, func(ref ContainerRef) error {
+		ref.Image = "changed"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if podSpec.Containers[0].Image != "nginx:1.27" {
+		t.Fatalf("Container mutated: %+v", podSpec.Containers[0])
+	}
+}
@@ -0,0 +1,184 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/oci/manifest_digest"
+	"github.com/kubewarden/policy-sdk-go/pkg/imageref"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// PinImageDigestsOption changes the behavior of PinImageDigests.
+type PinImageDigestsOption func(*imagePinning)
+
+// WithPinningAllowlist resolves only the images matching the allowlist, the
+// other ones are left untouched. An empty allowlist resolves all the
+// images.
+func WithPinningAllowlist(allowlist imageref.Allowlist) PinImageDigestsOption {
+	return func(p *imagePinning) {
+		p.allowlist = allowlist
+	}
+}
+
+type imagePinning struct {
+	allowlist imageref.Allowlist
+}
+
+// ImageDigestError is returned when the digest of an image cannot be
+// resolved.
+type ImageDigestError struct {
+	// Image is the image, as written inside of the container
+	Image string
+	// Path of the container inside of the object, e.g.
+	// `spec.template.spec.containers[0]`
+	Path rawjson.Path
+	// Err is the error returned while parsing the image reference or while
+	// querying the registry
+	Err error
+}
+
+func (e *ImageDigestError) Error() string {
+	return fmt.Sprintf("%s: cannot resolve the digest of image %s: %s", e.Path, e.Image, e.Err)
+}
+
+func (e *ImageDigestError) Unwrap() error {
+	return e.Err
+}
+
+// PinImageDigestsError collects the errors of all the images whose digest
+// cannot be resolved.
+type PinImageDigestsError struct {
+	Errors []*ImageDigestError
+}
+
+func (e *PinImageDigestsError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *PinImageDigestsError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// PinImageDigests resolves the tag of each container image of the
+// workload to a digest, querying the registry with
+// `manifest_digest.GetOCIManifestDigest`, and returns a mutation rewriting
+// the images to their digest-pinned form, like `nginx@sha256:...`. The
+// images already pinned are left untouched, as the ones not matching the
+// allowlist given with WithPinningAllowlist. The request is accepted
+// without changes when there's nothing to pin.
+//
+// All the images are processed before returning a PinImageDigestsError,
+// which lists the images that cannot be resolved. The error comes together
+// with the response pinning the images that could be resolved: policies
+// can choose to reject the request or to accept the partial mutation. It
+// returns an UnsupportedWorkloadKindError when the object doesn't embed a
+// pod template.
+func PinImageDigests(
+	host *capabilities.Host,
+	validationRequest protocol.ValidationRequest,
+	opts ...PinImageDigestsOption,
+) ([]byte, error) {
+	workload, err := NewWorkload(validationRequest, WithMissingPodSpecAsEmpty())
+	if err != nil {
+		return nil, err
+	}
+	changed, err := workload.PinImageDigests(host, opts...)
+	var pinErr *PinImageDigestsError
+	if err != nil && !errors.As(err, &pinErr) {
+		return nil, err
+	}
+
+	response, responseErr := AcceptRequest()
+	if changed {
+		response, responseErr = workload.Mutate()
+	}
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return response, err
+}
+
+// PinImageDigests rewrites the container images of the workload to their
+// digest-pinned form, like the PinImageDigests function does, and tells
+// whether some images have been changed. Only the `image` fields are
+// changed, the rest of the object is left untouched.
+//
+// When a PinImageDigestsError is returned, the images that could be
+// resolved have been pinned anyway: policies can choose to reject the
+// request or to accept the partial mutation.
+func (w *Workload) PinImageDigests(host *capabilities.Host, opts ...PinImageDigestsOption) (bool, error) {
+	pinning := imagePinning{}
+	for _, opt := range opts {
+		opt(&pinning)
+	}
+
+	podSpec, err := w.PodSpec()
+	if err != nil {
+		return false, err
+	}
+
+	digests := map[string]string{}
+	changed := false
+	pinErr := &PinImageDigestsError{}
+	err = ForEachContainer(podSpec, func(container ContainerRef) error {
+		path := w.PodSpecPath().Join(container.Path)
+		pinned, resolveErr := pinning.pin(host, container.Image, digests)
+		if resolveErr != nil {
+			pinErr.Errors = append(pinErr.Errors, &ImageDigestError{Image: container.Image, Path: path, Err: resolveErr})
+			return nil
+		}
+		if pinned == container.Image {
+			return nil
+		}
+
+		changed = true
+		return w.set(path.Key("image"), pinned)
+	})
+	if err != nil {
+		return changed, err
+	}
+	if len(pinErr.Errors) > 0 {
+		return changed, pinErr
+	}
+	return changed, nil
+}
+
+// pin returns the digest-pinned form of the image, or the image itself when
+// it must not be pinned. The digests already resolved are cached, so that
+// each image is resolved once.
+func (p *imagePinning) pin(host *capabilities.Host, image string, digests map[string]string) (string, error) {
+	ref, err := imageref.Parse(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.IsPinned() || (!p.allowlist.IsEmpty() && !p.allowlist.Matches(ref)) {
+		return image, nil
+	}
+
+	digest, found := digests[image]
+	if !found {
+		digest, err = manifest_digest.GetOCIManifestDigest(host, image)
+		if err != nil {
+			return "", err
+		}
+		digests[image] = digest
+	}
+	if _, err = ref.WithDigest(digest); err != nil {
+		return "", err
+	}
+
+	// keep the image as written by the user, replacing only its tag
+	return strings.TrimSuffix(image, ":"+ref.Tag) + "@" + digest, nil
+}
@@ -0,0 +1,171 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+	"github.com/kubewarden/policy-sdk-go/pkg/imageref"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const (
+	nginxDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	appDigest   = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	pinned      = "busybox@sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+func expectDigest(client *mocks.MockWapcClient, image, digest string, err error) {
+	payload, _ := json.Marshal(image)
+	response, _ := json.Marshal(map[string]string{"digest": digest})
+	client.EXPECT().
+		HostCall("kubewarden", "oci", "v1/manifest_digest", payload).
+		Return(response, err).
+		Times(1)
+}
+
+func newPinningRequest(podSpec string) protocol.ValidationRequest {
+	return protocol.ValidationRequest{
+		Request: protocol.KubernetesAdmissionRequest{
+			Kind:   protocol.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Object: json.RawMessage(`{"kind": "Deployment", "spec": {"template": {"spec": ` + podSpec + `}}}`),
+		},
+	}
+}
+
+func TestPinImageDigests(t *testing.T) {
+	client := &mocks.MockWapcClient{}
+	expectDigest(client, "nginx:1.25", nginxDigest, nil)
+	expectDigest(client, "ghcr.io/kubewarden/app", appDigest, nil)
+	host := &capabilities.Host{Client: client}
+
+	request := newPinningRequest(`{
+		"containers": [{"name": "nginx", "image": "nginx:1.25", "futureField": 1}, {"name": "app", "image": "ghcr.io/kubewarden/app"}],
+		"initContainers": [{"name": "setup", "image": "` + pinned + `"}, {"name": "copy", "image": "nginx:1.25"}]
+	}`)
+	response, err := PinImageDigests(host, request)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `{"accepted":true,"mutated_object":{"kind":"Deployment","spec":{"template":{"spec":{` +
+		`"containers":[{"name":"nginx","image":"nginx@` + nginxDigest + `","futureField":1},` +
+		`{"name":"app","image":"ghcr.io/kubewarden/app@` + appDigest + `"}],` +
+		`"initContainers":[{"name":"setup","image":"` + pinned + `"},{"name":"copy","image":"nginx@` + nginxDigest + `"}]` +
+		`}}}}}`
+	if string(response) != expected {
+		t.Fatalf("Unexpected response: %s", response)
+	}
+	client.AssertExpectations(t)
+}
+
+func TestPinImageDigestsWithAllowlist(t *testing.T) {
+	client := &mocks.MockWapcClient{}
+	expectDigest(client, "ghcr.io/kubewarden/app:v1", appDigest, nil)
+	host := &capabilities.Host{Client: client}
+
+	request := newPinningRequest(`{"containers": [{"image": "nginx:1.25"}, {"image": "ghcr.io/kubewarden/app:v1"}]}`)
+	allowlist := imageref.Allowlist{Registries: []string{"ghcr.io"}}
+	response, err := PinImageDigests(host, request, WithPinningAllowlist(allowlist))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `{"accepted":true,"mutated_object":{"kind":"Deployment","spec":{"template":{"spec":{` +
+		`"containers":[{"image":"nginx:1.25"},{"image":"ghcr.io/kubewarden/app@` + appDigest + `"}]}}}}}`
+	if string(response) != expected {
+		t.Fatalf("Unexpected response: %s", response)
+	}
+	client.AssertExpectations(t)
+}
+
+func TestPinImageDigestsWithoutChanges(t *testing.T) {
+	host := &capabilities.Host{Client: &mocks.MockWapcClient{}}
+
+	response, err := PinImageDigests(host, newPinningRequest(`{"containers": [{"image": "`+pinned+`"}]}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if string(response) != `{"accepted":true}` {
+		t.Fatalf("Unexpected response: %s", response)
+	}
+}
+
+func TestPinImageDigestsErrors(t *testing.T) {
+	registryErr := errors.New("manifest unknown")
+	client := &mocks.MockWapcClient{}
+	expectDigest(client, "nginx:1.25", nginxDigest, nil)
+	expectDigest(client, "ghcr.io/kubewarden/missing:v1", "", registryErr)
+	host := &capabilities.Host{Client: client}
+
+	workload, err := NewWorkload(newPinningRequest(`{
+		"containers": [{"image": "ghcr.io/kubewarden/missing:v1"}, {"image": "nginx:1.25"}, {"image": "Invalid"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	changed, err := workload.PinImageDigests(host)
+	var pinErr *PinImageDigestsError
+	if !errors.As(err, &pinErr) || len(pinErr.Errors) != 2 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !errors.Is(err, registryErr) || !errors.Is(err, imageref.ErrInvalidReference) {
+		t.Fatalf("The error should wrap the errors of the images: %v", err)
+	}
+	expectedError := "spec.template.spec.containers[0]: cannot resolve the digest of image " +
+		"ghcr.io/kubewarden/missing:v1: manifest unknown; " +
+		"spec.template.spec.containers[2]: cannot resolve the digest of image Invalid: " +
+		`invalid image reference "Invalid": repository name must be lowercase`
+	if err.Error() != expectedError {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the images that could be resolved are pinned anyway
+	if !changed {
+		t.Fatalf("The workload should have been changed")
+	}
+	podSpec, err := workload.PodSpec()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if podSpec.Containers[1].Image != "nginx@"+nginxDigest {
+		t.Fatalf("Unexpected image: %s", podSpec.Containers[1].Image)
+	}
+	client.AssertExpectations(t)
+}
+
+func TestPinImageDigestsPartialResponse(t *testing.T) {
+	client := &mocks.MockWapcClient{}
+	expectDigest(client, "nginx:1.25", nginxDigest, nil)
+	expectDigest(client, "ghcr.io/kubewarden/missing:v1", "", errors.New("manifest unknown"))
+	host := &capabilities.Host{Client: client}
+
+	request := newPinningRequest(`{"containers": [{"image": "ghcr.io/kubewarden/missing:v1"}, {"image": "nginx:1.25"}]}`)
+	response, err := PinImageDigests(host, request)
+	var pinErr *PinImageDigestsError
+	if !errors.As(err, &pinErr) || len(pinErr.Errors) != 1 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"accepted":true,"mutated_object":{"kind":"Deployment","spec":{"template":{"spec":{` +
+		`"containers":[{"image":"ghcr.io/kubewarden/missing:v1"},{"image":"nginx@` + nginxDigest + `"}]` +
+		`}}}}}`
+	if string(response) != expected {
+		t.Fatalf("Unexpected response: %s", response)
+	}
+
+	// policies can reject the request instead of accepting the partial mutation
+	rejection, err := RejectRequest(Message(err.Error()), NoCode)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expectedRejection := `{"accepted":false,"message":"spec.template.spec.containers[0]: ` +
+		`cannot resolve the digest of image ghcr.io/kubewarden/missing:v1: manifest unknown"}`
+	if string(rejection) != expectedRejection {
+		t.Fatalf("Unexpected rejection: %s", rejection)
+	}
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubewarden/policy-sdk-go/internal/jsonvalue"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// CheckImmutable compares the object of an UPDATE request with the old
+// object, and returns a violation for each one of the given paths whose
+// value has been changed. Requests performing other operations never
+// produce violations.
+//
+// The paths use the syntax of `rawjson.Path`, including the wildcards: for
+// example `spec.storageClassName`, `metadata.labels["app.kubernetes.io/name"]`,
+// `spec.containers[*].image` or `metadata.labels.*`. Adding or removing a
+// value is considered a change, while a missing value and a `null` one are
+// considered equal.
+//
+// Each violation reports the path of the changed value, together with the
+// old and the new values. The returned set can be used to build the
+// response:
+//
+//	violations, err := kubewarden.CheckImmutable(validationRequest, "spec.storageClassName")
+//	if err != nil {
+//		return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
+//	}
+//	return violations.Response(kubewarden.NoCode)
+func CheckImmutable(validationRequest protocol.ValidationRequest, paths ...string) (ViolationSet, error) {
+	violations := ViolationSet{}
+	request := validationRequest.Request
+	if request.Operation != protocol.OperationUpdate {
+		return violations, nil
+	}
+	if !request.HasOldObject() {
+		return ViolationSet{}, fmt.Errorf("%w (operation %s)", protocol.ErrNoOldObject, request.Operation)
+	}
+	if !request.HasObject() {
+		return ViolationSet{}, fmt.Errorf("%w (operation %s)", protocol.ErrNoObject, request.Operation)
+	}
+
+	for _, path := range paths {
+		pattern, err := rawjson.ParsePath(path)
+		if err != nil {
+			return ViolationSet{}, err
+		}
+
+		changes, err := findChanges(request.OldObject, request.Object, pattern)
+		if err != nil {
+			return ViolationSet{}, err
+		}
+		for _, change := range changes {
+			violations.Addf(change.path.String(), CauseTypeFieldValueForbidden, "field is immutable: changed from %s to %s",
+				formatValue(change.oldValue), formatValue(change.newValue))
+		}
+	}
+	return violations, nil
+}
+
+// valueChange describes a value that differs between two documents. Missing
+// values are nil.
+type valueChange struct {
+	path     rawjson.Path
+	oldValue json.RawMessage
+	newValue json.RawMessage
+}
+
+// findChanges returns the values matched by the pattern that differ
+// between the old and the new document, in the order they appear inside of
+// the old document, followed by the values added by the new one.
+func findChanges(oldObject, newObject []byte, pattern rawjson.Path) ([]valueChange, error) {
+	oldPaths, err := rawjson.Expand(oldObject, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot inspect old object: %w", err)
+	}
+	newPaths, err := rawjson.Expand(newObject, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot inspect object: %w", err)
+	}
+
+	changes := []valueChange{}
+	visited := map[string]bool{}
+	for _, path := range append(oldPaths, newPaths...) {
+		if visited[path.String()] {
+			continue
+		}
+		visited[path.String()] = true
+
+		oldValue := lookupValue(oldObject, path)
+		newValue := lookupValue(newObject, path)
+		if !equalValues(oldValue, newValue) {
+			changes = append(changes, valueChange{path: path, oldValue: oldValue, newValue: newValue})
+		}
+	}
+	return changes, nil
+}
+
+// lookupValue returns the value referenced by the path, or nil when it
+// doesn't exist, it's null or the document has a different structure.
+func lookupValue(document []byte, path rawjson.Path) json.RawMessage {
+	value, found, err := rawjson.Get(document, path)
+	if err != nil || !found || bytes.Equal(value, []byte("null")) {
+		return nil
+	}
+	return value
+}
+
+// equalValues compares two JSON values, ignoring their formatting and the
+// order of the object keys. Numbers are compared by value, large integers
+// don't lose precision.
+func equalValues(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	aValue, aErr := decodeValue(a)
+	bValue, bErr := decodeValue(b)
+	if aErr != nil || bErr != nil {
+		return bytes.Equal(a, b)
+	}
+	return jsonvalue.Equal(aValue, bValue)
+}
+
+// decodeValue unmarshals a JSON value, numbers are kept as `json.Number`.
+func decodeValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func formatValue(value json.RawMessage) string {
+	if value == nil {
+		return "<unset>"
+	}
+	compacted := bytes.Buffer{}
+	if json.Compact(&compacted, value) != nil {
+		return string(value)
+	}
+	return compacted.String()
+}
@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const immutableOldObject = `{
+  "metadata": {"labels": {"app": "web", "team": "a"}},
+  "spec": {
+    "storageClassName": "standard",
+    "replicas": 9007199254740993,
+    "containers": [{"name": "nginx", "image": "nginx:1.25"}, {"name": "sidecar", "image": "busybox"}]
+  }
+}`
+
+func updateRequest(oldObject, newObject string) protocol.ValidationRequest {
+	return protocol.ValidationRequest{
+		Request: protocol.KubernetesAdmissionRequest{
+			Operation: protocol.OperationUpdate,
+			Object:    json.RawMessage(newObject),
+			OldObject: json.RawMessage(oldObject),
+		},
+	}
+}
+
+func TestCheckImmutable(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		object             string
+		paths              []string
+		expectedViolations []Violation
+	}{
+		"Unchanged": {
+			object: `{"spec": {"containers": [{"image": "nginx:1.25", "name": "nginx"}, {"name": "sidecar", "image": "busybox"}],
+				"storageClassName": "standard", "replicas": 9007199254740993}, "metadata": {"labels": {"team": "a", "app": "web"}}}`,
+			paths: []string{"spec.storageClassName", "spec.replicas", "spec.containers[*].image", "metadata.labels.*"},
+		},
+		"ChangedValue": {
+			object: `{"spec": {"storageClassName": "fast"}}`,
+			paths:  []string{"spec.storageClassName"},
+			expectedViolations: []Violation{{
+				Field:   "spec.storageClassName",
+				Message: `field is immutable: changed from "standard" to "fast"`,
+				Reason:  CauseTypeFieldValueForbidden,
+			}},
+		},
+		"EqualNumbers": {
+			object: `{"spec": {"replicas": 9007199254740993.0}}`,
+			paths:  []string{"spec.replicas"},
+		},
+		"LargeInteger": {
+			object: `{"spec": {"replicas": 9007199254740992}}`,
+			paths:  []string{"spec.replicas"},
+			expectedViolations: []Violation{{
+				Field:   "spec.replicas",
+				Message: "field is immutable: changed from 9007199254740993 to 9007199254740992",
+				Reason:  CauseTypeFieldValueForbidden,
+			}},
+		},
+		"ArrayWildcard": {
+			object: `{"spec": {"containers": [{"name": "nginx", "image": "nginx:1.26"}, {"name": "sidecar", "image": "busybox"},
+				{"name": "debug", "image": "alpine"}]}}`,
+			paths: []string{"spec.containers[*].image"},
+			expectedViolations: []Violation{
+				{
+					Field:   "spec.containers[0].image",
+					Message: `field is immutable: changed from "nginx:1.25" to "nginx:1.26"`,
+					Reason:  CauseTypeFieldValueForbidden,
+				},
+				{
+					Field:   "spec.containers[2].image",
+					Message: `field is immutable: changed from <unset> to "alpine"`,
+					Reason:  CauseTypeFieldValueForbidden,
+				},
+			},
+		},
+		"MapWildcard": {
+			object: `{"metadata": {"labels": {"app": "api", "owner": "b"}}}`,
+			paths:  []string{"metadata.labels.*"},
+			expectedViolations: []Violation{
+				{
+					Field:   "metadata.labels.app",
+					Message: `field is immutable: changed from "web" to "api"`,
+					Reason:  CauseTypeFieldValueForbidden,
+				},
+				{
+					Field:   "metadata.labels.team",
+					Message: `field is immutable: changed from "a" to <unset>`,
+					Reason:  CauseTypeFieldValueForbidden,
+				},
+				{
+					Field:   "metadata.labels.owner",
+					Message: `field is immutable: changed from <unset> to "b"`,
+					Reason:  CauseTypeFieldValueForbidden,
+				},
+			},
+		},
+		"WholeObject": {
+			object: `{"metadata": {"labels": {"app": "web", "team": "a"}}, "spec": {"storageClassName": null}}`,
+			paths:  []string{"spec.storageClassName", "metadata"},
+			expectedViolations: []Violation{{
+				Field:   "spec.storageClassName",
+				Message: `field is immutable: changed from "standard" to <unset>`,
+				Reason:  CauseTypeFieldValueForbidden,
+			}},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			violations, err := CheckImmutable(updateRequest(immutableOldObject, testCase.object), testCase.paths...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expectedViolations, violations.Violations()); diff != "" {
+				t.Fatalf("unexpected violations:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckImmutableOtherOperations(t *testing.T) {
+	request := updateRequest(immutableOldObject, `{}`)
+	request.Request.Operation = protocol.OperationCreate
+
+	violations, err := CheckImmutable(request, "spec.storageClassName")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !violations.IsEmpty() {
+		t.Fatalf("unexpected violations: %s", violations.Message())
+	}
+}
+
+func TestCheckImmutableErrors(t *testing.T) {
+	if _, err := CheckImmutable(updateRequest(immutableOldObject, `{}`), "spec..storageClassName"); err == nil {
+		t.Fatalf("expected an error for the invalid path")
+	}
+	if _, err := CheckImmutable(updateRequest("", `{}`), "spec"); !errors.Is(err, protocol.ErrNoOldObject) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := CheckImmutable(updateRequest(`{}`, `{`), "spec"); err == nil {
+		t.Fatalf("expected an error for the invalid object")
+	}
+}
@@ -0,0 +1,16 @@
+// This package finds the repeated entries of the lists provided by the
+// users, e.g. inside of the policy settings.
+package duplicates
+
+import "slices"
+
+// Find returns the first value that occurs more than once inside of the
+// list, if any.
+func Find(values []string) (string, bool) {
+	for i, value := range values {
+		if slices.Contains(values[i+1:], value) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,28 @@
+package duplicates
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		values            []string
+		expectedDuplicate string
+		expectedFound     bool
+	}{
+		"Empty": {},
+		"Unique": {
+			values: []string{"a", "b", "c"},
+		},
+		"Duplicate": {
+			values:            []string{"a", "b", "c", "b", "a"},
+			expectedDuplicate: "a",
+			expectedFound:     true,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			duplicate, found := Find(testCase.values)
+			if duplicate != testCase.expectedDuplicate || found != testCase.expectedFound {
+				t.Fatalf("unexpected result: %q, %t", duplicate, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// This package compares the JSON values decoded into generic maps and
+// slices, with the numbers kept as `json.Number`.
+package jsonvalue
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent is the largest exponent of the numbers compared by value,
+// larger ones are compared by their representation to keep the comparison
+// cheap.
+const maxExponent = 1000
+
+// Equal compares two decoded JSON values. Numbers are compared by value,
+// e.g. `1`, `1.0` and `1e0` are equal, without losing the precision of the
+// large integers.
+func Equal(a, b interface{}) bool {
+	switch aValue := a.(type) {
+	case map[string]interface{}:
+		bValue, ok := b.(map[string]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for key, value := range aValue {
+			other, found := bValue[key]
+			if !found || !Equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bValue, ok := b.([]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for i := range aValue {
+			if !Equal(aValue[i], bValue[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bValue, ok := b.(json.Number)
+		return ok && equalNumbers(aValue, bValue)
+	default:
+		return a == b
+	}
+}
+
+// equalNumbers compares the numbers by value, falling back to their
+// representation when they cannot be parsed.
+func equalNumbers(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	aValue, aOk := parseNumber(a)
+	bValue, bOk := parseNumber(b)
+	if !aOk || !bOk {
+		return false
+	}
+	return aValue.Cmp(bValue) == 0
+}
+
+func parseNumber(number json.Number) (*big.Rat, bool) {
+	text := string(number)
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exponent, err := strconv.Atoi(text[i+1:])
+		if err != nil || exponent > maxExponent || exponent < -maxExponent {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(text)
+}
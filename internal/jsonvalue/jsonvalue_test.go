@@ -0,0 +1,33 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		a, b     interface{}
+		expected bool
+	}{
+		"SameNumber":       {a: json.Number("1"), b: json.Number("1"), expected: true},
+		"Fraction":         {a: json.Number("1"), b: json.Number("1.0"), expected: true},
+		"Exponent":         {a: json.Number("100"), b: json.Number("1e2"), expected: true},
+		"DifferentNumbers": {a: json.Number("1"), b: json.Number("1.5"), expected: false},
+		"LargeIntegers":    {a: json.Number("9007199254740993"), b: json.Number("9007199254740992"), expected: false},
+		"HugeExponent":     {a: json.Number("1e2147483647"), b: json.Number("1e2147483646"), expected: false},
+		"NumberAndString":  {a: json.Number("1"), b: "1", expected: false},
+		"Objects":          {a: map[string]interface{}{"a": json.Number("1")}, b: map[string]interface{}{"a": json.Number("1.0")}, expected: true},
+		"DifferentObjects": {a: map[string]interface{}{"a": json.Number("1")}, b: map[string]interface{}{"b": json.Number("1")}, expected: false},
+		"Arrays":           {a: []interface{}{json.Number("1"), "a"}, b: []interface{}{json.Number("1e0"), "a"}, expected: true},
+		"DifferentLengths": {a: []interface{}{json.Number("1")}, b: []interface{}{}, expected: false},
+		"Null":             {a: nil, b: nil, expected: true},
+		"NullAndFalse":     {a: nil, b: false, expected: false},
+	} {
+		t.Run(description, func(t *testing.T) {
+			if Equal(testCase.a, testCase.b) != testCase.expected {
+				t.Fatalf("expected Equal to be %t", testCase.expected)
+			}
+		})
+	}
+}
@@ -6,10 +6,11 @@ package sdk
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 
-	appsv1 "github.com/kubewarden/k8s-objects/api/apps/v1"
-	batchv1 "github.com/kubewarden/k8s-objects/api/batch/v1"
 	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonpatch"
 	"github.com/kubewarden/policy-sdk-go/protocol"
 )
 
@@ -32,11 +33,14 @@ const (
 // AcceptRequest can be used inside of the `validate` function to accept the
 // incoming request.
 func AcceptRequest() ([]byte, error) {
-	response := protocol.ValidationResponse{
-		Accepted: true,
-	}
+	return NewAcceptResponse().Build()
+}
 
-	return json.Marshal(response)
+// AcceptRequestWithWarnings can be used inside of the `validate` function to
+// accept the incoming request while showing some warnings to the user.
+// Warnings are useful to inform users about deprecated fields or values.
+func AcceptRequestWithWarnings(warnings ...string) ([]byte, error) {
+	return NewAcceptResponse().WithWarnings(warnings...).Build()
 }
 
 // RejectRequest can be used inside of the `validate` function to reject the
@@ -44,30 +48,99 @@ func AcceptRequest() ([]byte, error) {
 // * `message`: optional message to show to the user
 // * `code`: optional error code to show to the user.
 func RejectRequest(message Message, code Code) ([]byte, error) {
-	response := protocol.ValidationResponse{
-		Accepted: false,
+	return NewRejectResponse(message, code).Build()
+}
+
+// MutateRequest accepts the request and mutate the final object to match the
+// one provided via the `newObject` param.
+func MutateRequest(newObject interface{}) ([]byte, error) {
+	return NewMutateResponse(newObject).Build()
+}
+
+// MutateRequestWithPatch accepts the request and mutates the final object
+// to match the one provided via the `newObject` param. Instead of sending
+// back the whole object, the response carries the JSON Patch between the
+// object of the request and `newObject`.
+// * `validationRequest` - the original admission request
+// * `newObject` - the mutated object, must be serializable to JSON.
+//
+// When `newObject` is a Go type, like the ones of k8s-objects, only the
+// values changed by the policy are patched: the object of the request is
+// decoded into the same type, and the changes are computed against it. The
+// fields unknown to the type and the values serialized differently, like
+// timestamps, are left untouched. Raw JSON documents (`[]byte` and
+// `json.RawMessage`) are compared with the object of the request as they
+// are.
+func MutateRequestWithPatch(validationRequest protocol.ValidationRequest, newObject interface{}) ([]byte, error) {
+	switch value := newObject.(type) {
+	case json.RawMessage:
+		return mutateRequestWithRawPatch(validationRequest, value)
+	case []byte:
+		return mutateRequestWithRawPatch(validationRequest, value)
 	}
-	if message != NoMessage {
-		msg := string(message)
-		response.Message = &msg
+
+	newObjectRaw, err := json.Marshal(newObject)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize mutated object: %w", err)
 	}
-	if code != NoCode {
-		c := uint16(code)
-		response.Code = &c
+	originalObjectRaw, err := roundTrip(validationRequest.Request.Object, newObject)
+	if err != nil {
+		return nil, err
 	}
 
-	return json.Marshal(response)
+	patch, err := jsonpatch.CreatePatchFromChanges(validationRequest.Request.Object, originalObjectRaw, newObjectRaw)
+	if err != nil {
+		return nil, err
+	}
+	return MutateRequestWithPatchOperations(patch)
 }
 
-// MutateRequest accepts the request and mutate the final object to match the
-// one provided via the `newObject` param.
-func MutateRequest(newObject interface{}) ([]byte, error) {
-	response := protocol.ValidationResponse{
-		Accepted:      true,
-		MutatedObject: newObject,
+func mutateRequestWithRawPatch(validationRequest protocol.ValidationRequest, newObject []byte) ([]byte, error) {
+	patch, err := jsonpatch.CreatePatch(validationRequest.Request.Object, newObject)
+	if err != nil {
+		return nil, err
 	}
+	return MutateRequestWithPatchOperations(patch)
+}
 
-	return json.Marshal(response)
+// roundTrip decodes the object into the Go type of `target` and serializes
+// it back, dropping the fields unknown to the type like the policy did.
+func roundTrip(object json.RawMessage, target interface{}) ([]byte, error) {
+	targetType := reflect.TypeOf(target)
+	if targetType == nil {
+		return object, nil
+	}
+	if targetType.Kind() == reflect.Pointer {
+		targetType = targetType.Elem()
+	}
+
+	decoded := reflect.New(targetType).Interface()
+	if err := json.Unmarshal(object, decoded); err != nil {
+		return nil, fmt.Errorf("cannot decode the object of the request: %w", err)
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize the object of the request: %w", err)
+	}
+	return data, nil
+}
+
+// MutateRequestWithPatchOperations accepts the request and mutates the
+// object of the request by applying the given JSON Patch operations.
+func MutateRequestWithPatchOperations(patch jsonpatch.Patch) ([]byte, error) {
+	builder, err := NewPatchResponse(patch)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
+
+// MutateRawObject accepts the request and mutates the final object to
+// match the given raw JSON document. Use it together with the functions of
+// the `pkg/rawjson` package to change only some fields of the object of the
+// request, without losing the ones unknown to the Kubernetes Go types.
+func MutateRawObject(newObject []byte) ([]byte, error) {
+	return MutateRequest(json.RawMessage(newObject))
 }
 
 // MutatePodSpecFromRequest updates the pod spec from the resource defined in the original object and
@@ -75,69 +148,28 @@ func MutateRequest(newObject interface{}) ([]byte, error) {
 // * `validation_request` - the original admission request
 // * `pod_spec` - new PodSpec to be set in the response.
 //
-//nolint:funlen // Splitting this function would not make it more readable.
-func MutatePodSpecFromRequest(validationRequest protocol.ValidationRequest, podSepc corev1.PodSpec) ([]byte, error) {
-	switch validationRequest.Request.Kind.Kind {
-	case "Deployment":
-		deployment := appsv1.Deployment{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &deployment); err != nil {
-			return nil, err
-		}
-		deployment.Spec.Template.Spec = &podSepc
-		return MutateRequest(deployment)
-	case "ReplicaSet":
-		replicaset := appsv1.ReplicaSet{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &replicaset); err != nil {
-			return nil, err
-		}
-		replicaset.Spec.Template.Spec = &podSepc
-		return MutateRequest(replicaset)
-	case "StatefulSet":
-		statefulset := appsv1.StatefulSet{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &statefulset); err != nil {
-			return nil, err
-		}
-		statefulset.Spec.Template.Spec = &podSepc
-		return MutateRequest(statefulset)
-	case "DaemonSet":
-		daemonset := appsv1.DaemonSet{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &daemonset); err != nil {
-			return nil, err
-		}
-		daemonset.Spec.Template.Spec = &podSepc
-		return MutateRequest(daemonset)
-	case "ReplicationController":
-		replicationController := corev1.ReplicationController{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &replicationController); err != nil {
-			return nil, err
-		}
-		replicationController.Spec.Template.Spec = &podSepc
-		return MutateRequest(replicationController)
-	case "CronJob":
-		cronjob := batchv1.CronJob{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &cronjob); err != nil {
-			return nil, err
-		}
-		cronjob.Spec.JobTemplate.Spec.Template.Spec = &podSepc
-		return MutateRequest(cronjob)
-	case "Job":
-		job := batchv1.Job{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &job); err != nil {
-			return nil, err
-		}
-		job.Spec.Template.Spec = &podSepc
-		return MutateRequest(job)
-	case "Pod":
-		pod := corev1.Pod{}
-		if err := json.Unmarshal(validationRequest.Request.Object, &pod); err != nil {
-			return nil, err
-		}
-		pod.Spec = &podSepc
-		return MutateRequest(pod)
-	default:
-		return RejectRequest("Object should be one of these kinds: Deployment, "+
-			"ReplicaSet, StatefulSet, DaemonSet, ReplicationController, Job, CronJob, Pod", NoCode)
+// Only the PodSpec is replaced, the rest of the object is left untouched.
+// Objects supported are the ones known by NewWorkload. An error wrapping
+// ErrMissingPodSpec is returned when the object doesn't have a PodSpec,
+// use WithMissingPodSpecAsEmpty to add it instead.
+func MutatePodSpecFromRequest(
+	validationRequest protocol.ValidationRequest,
+	podSepc corev1.PodSpec,
+	opts ...WorkloadOption,
+) ([]byte, error) {
+	workload, err := NewWorkload(validationRequest, opts...)
+	if err != nil {
+		var unsupportedKindErr *UnsupportedWorkloadKindError
+		if errors.As(err, &unsupportedKindErr) {
+			return RejectRequest(Message("Object should be one of these kinds: "+supportedWorkloadKindNames()), NoCode)
+		}
+		return nil, err
+	}
+
+	if err = workload.SetPodSpec(podSepc); err != nil {
+		return nil, err
 	}
+	return workload.Mutate()
 }
 
 // AcceptSettings can be used inside of the `validate_settings` function to
@@ -169,62 +201,16 @@ func RejectSettings(message Message) ([]byte, error) {
 // For example, it can be used to reject Deployments or StatefulSets
 // that violate a policy instead of the Pods created by them.
 // Objects supported are: Deployment, ReplicaSet, StatefulSet,
-// DaemonSet, ReplicationController, Job, CronJob, Pod It returns an error if
-// the object is not one of those. If it is a supported object it returns the
-// PodSpec if present, otherwise returns an empty PodSpec.
+// DaemonSet, ReplicationController, Job, CronJob, Pod and the kinds added
+// with RegisterWorkloadKind. It returns an error if the object is not one
+// of those. If it is a supported object it returns the
+// PodSpec if present, otherwise returns an error wrapping ErrMissingPodSpec.
+// Use WithMissingPodSpecAsEmpty to get an empty PodSpec instead.
 // * `object`: the request to validate.
-func ExtractPodSpecFromObject(object protocol.ValidationRequest) (corev1.PodSpec, error) {
-	switch object.Request.Kind.Kind {
-	case "Deployment":
-		deployment := appsv1.Deployment{}
-		if err := json.Unmarshal(object.Request.Object, &deployment); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *deployment.Spec.Template.Spec, nil
-	case "ReplicaSet":
-		replicaset := appsv1.ReplicaSet{}
-		if err := json.Unmarshal(object.Request.Object, &replicaset); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *replicaset.Spec.Template.Spec, nil
-	case "StatefulSet":
-		statefulset := appsv1.StatefulSet{}
-		if err := json.Unmarshal(object.Request.Object, &statefulset); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *statefulset.Spec.Template.Spec, nil
-	case "DaemonSet":
-		daemonset := appsv1.DaemonSet{}
-		if err := json.Unmarshal(object.Request.Object, &daemonset); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *daemonset.Spec.Template.Spec, nil
-	case "ReplicationController":
-		replicationController := corev1.ReplicationController{}
-		if err := json.Unmarshal(object.Request.Object, &replicationController); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *replicationController.Spec.Template.Spec, nil
-	case "CronJob":
-		cronjob := batchv1.CronJob{}
-		if err := json.Unmarshal(object.Request.Object, &cronjob); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *cronjob.Spec.JobTemplate.Spec.Template.Spec, nil
-	case "Job":
-		job := batchv1.Job{}
-		if err := json.Unmarshal(object.Request.Object, &job); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *job.Spec.Template.Spec, nil
-	case "Pod":
-		pod := corev1.Pod{}
-		if err := json.Unmarshal(object.Request.Object, &pod); err != nil {
-			return corev1.PodSpec{}, err
-		}
-		return *pod.Spec, nil
-	default:
-		return corev1.PodSpec{}, errors.New("object should be one of these kinds: " +
-			"Deployment, ReplicaSet, StatefulSet, DaemonSet, ReplicationController, Job, CronJob, Pod")
+func ExtractPodSpecFromObject(object protocol.ValidationRequest, opts ...WorkloadOption) (corev1.PodSpec, error) {
+	workload, err := NewWorkload(object, opts...)
+	if err != nil {
+		return corev1.PodSpec{}, err
 	}
+	return workload.PodSpec()
 }
@@ -8,6 +8,8 @@ import (
 	appsv1 "github.com/kubewarden/k8s-objects/api/apps/v1"
 	batchv1 "github.com/kubewarden/k8s-objects/api/batch/v1"
 	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonpatch"
 	"github.com/kubewarden/policy-sdk-go/protocol"
 )
 
@@ -272,3 +274,135 @@ func TestMutatePodSpecFromRequestWithInvalidResourceType(t *testing.T) {
 		t.Fatalf("Different error occurred")
 	}
 }
+
+func TestMutateRequestWithPatch(t *testing.T) {
+	pod := corev1.Pod{
+		Metadata: &metav1.ObjectMeta{
+			Name: "nginx",
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "nginx",
+			},
+		},
+		Spec: &corev1.PodSpec{
+			Containers: []*corev1.Container{
+				{Image: "nginx"},
+			},
+		},
+	}
+	validationRequest, err := createValidationRequest(pod, "Pod")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	pod.Metadata.Labels["app.kubernetes.io/name"] = "web"
+	pod.Spec.Containers[0].Image = "nginx:1.27"
+
+	rawResponse, err := MutateRequestWithPatch(validationRequest, pod)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	response := protocol.ValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !response.Accepted {
+		t.Fatalf("Response not accepted")
+	}
+	if response.MutatedObject != nil {
+		t.Fatalf("Unexpected mutated object")
+	}
+	if response.PatchType == nil || *response.PatchType != protocol.PatchTypeJSONPatch {
+		t.Fatalf("Unexpected patch type: %v", response.PatchType)
+	}
+
+	expectedPatch := `[{"op":"replace","path":"/metadata/labels/app.kubernetes.io~1name","value":"web"},` +
+		`{"op":"replace","path":"/spec/containers/0/image","value":"nginx:1.27"}]`
+	if string(response.Patch) != expectedPatch {
+		t.Fatalf("Unexpected patch: %s", response.Patch)
+	}
+}
+
+func TestMutateRequestWithPatchPreservesUnknownFields(t *testing.T) {
+	object := `{"apiVersion":"v1","kind":"Pod",` +
+		`"metadata":{"name":"nginx","creationTimestamp":"2024-01-01T00:00:00Z","futureField":{"enabled":true}},` +
+		`"spec":{"containers":[{"name":"nginx","image":"nginx","futureContainerField":1}],"futureSpecField":"value"}}`
+	validationRequest := protocol.ValidationRequest{
+		Request: protocol.KubernetesAdmissionRequest{Object: json.RawMessage(object)},
+	}
+
+	pod := corev1.Pod{}
+	if err := json.Unmarshal([]byte(object), &pod); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	pod.Spec.Containers[0].Image = "nginx:1.27"
+	sidecar := "sidecar"
+	pod.Spec.Containers = append(pod.Spec.Containers, &corev1.Container{Name: &sidecar, Image: "busybox"})
+
+	rawResponse, err := MutateRequestWithPatch(validationRequest, &pod)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	response := protocol.ValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expectedPatch := `[{"op":"replace","path":"/spec/containers/0/image","value":"nginx:1.27"},` +
+		`{"op":"add","path":"/spec/containers/1","value":{"image":"busybox","name":"sidecar"}}]`
+	if string(response.Patch) != expectedPatch {
+		t.Fatalf("Unexpected patch: %s", response.Patch)
+	}
+
+	patched, err := jsonpatch.Apply([]byte(object), jsonpatch.Patch{
+		{Op: jsonpatch.OperationReplace, Path: "/spec/containers/0/image", Value: "nginx:1.27"},
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	rawResponse, err = MutateRequestWithPatch(validationRequest, json.RawMessage(patched))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	response = protocol.ValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if string(response.Patch) != `[{"op":"replace","path":"/spec/containers/0/image","value":"nginx:1.27"}]` {
+		t.Fatalf("Unexpected patch: %s", response.Patch)
+	}
+}
+
+func TestMutateRequestWithPatchWithoutChanges(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: &corev1.PodSpec{},
+	}
+	validationRequest, err := createValidationRequest(pod, "Pod")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	rawResponse, err := MutateRequestWithPatch(validationRequest, pod)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if string(rawResponse) != `{"accepted":true}` {
+		t.Fatalf("Unexpected response: %s", rawResponse)
+	}
+}
+
+func TestMutateRawObject(t *testing.T) {
+	object := []byte(`{"kind": "Pod", "spec": {"futureField": true}}`)
+
+	rawResponse, err := MutateRawObject(object)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `{"accepted":true,"mutated_object":{"kind":"Pod","spec":{"futureField":true}}}`
+	if string(rawResponse) != expected {
+		t.Fatalf("Unexpected response: %s", rawResponse)
+	}
+}
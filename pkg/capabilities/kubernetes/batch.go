@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+)
+
+// getResourcesOperation is the host operation fetching many resources.
+const getResourcesOperation = "get_resources"
+
+// getResourcesRequest is the payload of the `get_resources` operation.
+type getResourcesRequest struct {
+	Requests []GetResourceRequest `json:"requests"`
+}
+
+// getResourcesResponseItem is the result of one of the requests of the
+// `get_resources` operation, it holds either the resource or an error.
+type getResourcesResponseItem struct {
+	Resource json.RawMessage `json:"resource,omitempty"`
+	Error    *string         `json:"error,omitempty"`
+}
+
+// GetResourceResult is the result of one of the requests of GetResources.
+type GetResourceResult struct {
+	// Resource is the resource, like the one returned by GetResource
+	Resource []byte
+	// Err is the error of the request, a *NotFoundError when the resource
+	// doesn't exist
+	Err error
+}
+
+// GetResources gets many Kubernetes resources with a single host call. The
+// results are returned in the order of the requests, each one with either
+// the resource or its error:
+//
+//	results, err := kubernetes.GetResources(host, []kubernetes.GetResourceRequest{
+//		{APIVersion: "v1", Kind: "Secret", Name: "token", Namespace: &namespace},
+//		{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Namespace: &namespace},
+//	})
+//
+// When the host doesn't support the `get_resources` operation, the
+// resources are fetched one by one using GetResource. The returned error
+// reports the failures of the whole call, like a transport failure.
+func GetResources(h *capabilities.Host, reqs []GetResourceRequest) ([]GetResourceResult, error) {
+	results := make([]GetResourceResult, len(reqs))
+
+	// the undeclared resources are reported without reaching the host
+	batch := getResourcesRequest{Requests: []GetResourceRequest{}}
+	indexes := []int{}
+	for i, req := range reqs {
+		if err := checkDeclaredResource(req.APIVersion, req.Kind); err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch.Requests = append(batch.Requests, req)
+		indexes = append(indexes, i)
+	}
+	if len(batch.Requests) == 0 {
+		return results, nil
+	}
+
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize request object: %w", err)
+	}
+
+	// perform callback
+	responsePayload, err := h.Client.HostCall("kubewarden", "kubernetes", getResourcesOperation, payload)
+	if err != nil {
+		if !isUnsupportedOperation(err) {
+			return nil, err
+		}
+		for _, i := range indexes {
+			resource, getErr := GetResource(h, reqs[i])
+			if getErr != nil {
+				results[i].Err = wrapNotFound(reqs[i], getErr)
+				continue
+			}
+			results[i].Resource = resource
+		}
+		return results, nil
+	}
+
+	response := []getResourcesResponseItem{}
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		return nil, fmt.Errorf("cannot unmarshall response object: %w", err)
+	}
+	if len(response) != len(batch.Requests) {
+		return nil, fmt.Errorf("the host returned %d results for %d requests", len(response), len(batch.Requests))
+	}
+	for j, i := range indexes {
+		if response[j].Error != nil {
+			results[i].Err = wrapNotFound(reqs[i], errors.New(*response[j].Error))
+			continue
+		}
+		results[i].Resource = response[j].Resource
+	}
+	return results, nil
+}
+
+// isUnsupportedOperation tells whether the host error is the one returned by
+// the older hosts, which don't know the `get_resources` operation.
+func isUnsupportedOperation(err error) bool {
+	return strings.TrimSpace(err.Error()) == "unknown operation: "+getResourcesOperation
+}
@@ -0,0 +1,160 @@
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func buildGetResourcesRequests() []GetResourceRequest {
+	namespace := "default"
+	return []GetResourceRequest{
+		{APIVersion: "v1", Kind: "Secret", Name: "token", Namespace: &namespace},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Namespace: &namespace},
+	}
+}
+
+// checkGetResourcesResults checks that the token Secret is found and the
+// settings ConfigMap is missing.
+func checkGetResourcesResults(t *testing.T, results []GetResourceResult) {
+	t.Helper()
+
+	if len(results) != 2 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	if results[0].Err != nil || string(results[0].Resource) != `{"metadata":{"name":"token"}}` {
+		t.Fatalf("unexpected result: %s, %v", results[0].Resource, results[0].Err)
+	}
+	var notFound *NotFoundError
+	if !errors.As(results[1].Err, &notFound) || notFound.Error() != "v1 ConfigMap default/settings not found" {
+		t.Fatalf("unexpected error: %v", results[1].Err)
+	}
+	if results[1].Resource != nil {
+		t.Fatalf("unexpected resource: %s", results[1].Resource)
+	}
+}
+
+func TestKubernetesGetResources(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+
+	expectedInputPayload := `{"requests":[` +
+		`{"api_version":"v1","kind":"Secret","name":"token","namespace":"default","disable_cache":false},` +
+		`{"api_version":"v1","kind":"ConfigMap","name":"settings","namespace":"default","disable_cache":false}]}`
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resources", []byte(expectedInputPayload)).
+		Return([]byte(`[{"resource":{"metadata":{"name":"token"}}},{"error":"configmaps \"settings\" not found: NotFound"}]`), nil).
+		Times(1)
+
+	results, err := GetResources(&capabilities.Host{Client: mockWapcClient}, buildGetResourcesRequests())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkGetResourcesResults(t, results)
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesGetResourcesFallback(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resources", mock.Anything).
+		Return(nil, errors.New("unknown operation: get_resources")).
+		Times(1)
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resource",
+			[]byte(`{"api_version":"v1","kind":"Secret","name":"token","namespace":"default","disable_cache":false}`)).
+		Return([]byte(`{"metadata":{"name":"token"}}`), nil).
+		Times(1)
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resource",
+			[]byte(`{"api_version":"v1","kind":"ConfigMap","name":"settings","namespace":"default","disable_cache":false}`)).
+		Return(nil, errors.New(`configmaps "settings" not found: NotFound`)).
+		Times(1)
+
+	results, err := GetResources(&capabilities.Host{Client: mockWapcClient}, buildGetResourcesRequests())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkGetResourcesResults(t, results)
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesGetResourcesErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		response      string
+		hostError     error
+		expectedError string
+	}{
+		"HostFailure": {
+			hostError:     errors.New("connection refused"),
+			expectedError: "connection refused",
+		},
+		"UnsupportedMediaType": {
+			hostError:     errors.New("unsupported media type"),
+			expectedError: "unsupported media type",
+		},
+		"InvalidResponse": {
+			response:      `{}`,
+			expectedError: "cannot unmarshall response object: json: cannot unmarshal object into Go value of type []kubernetes.getResourcesResponseItem",
+		},
+		"MissingResults": {
+			response:      `[{"resource":{}}]`,
+			expectedError: "the host returned 1 results for 2 requests",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			mockWapcClient := &mocks.MockWapcClient{}
+			mockWapcClient.
+				EXPECT().
+				HostCall("kubewarden", "kubernetes", "get_resources", mock.Anything).
+				Return([]byte(testCase.response), testCase.hostError).
+				Times(1)
+
+			_, err := GetResources(&capabilities.Host{Client: mockWapcClient}, buildGetResourcesRequests())
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestKubernetesGetResourcesUndeclared(t *testing.T) {
+	restoreDeclaredResources(t)
+
+	DeclareContextAwareResources(ResourceOf[corev1.ConfigMap]())
+
+	mockWapcClient := &mocks.MockWapcClient{}
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resources",
+			[]byte(`{"requests":[{"api_version":"v1","kind":"ConfigMap","name":"settings","namespace":"default","disable_cache":false}]}`)).
+		Return([]byte(`[{"resource":{"metadata":{"name":"settings"}}}]`), nil).
+		Times(1)
+
+	results, err := GetResources(&capabilities.Host{Client: mockWapcClient}, buildGetResourcesRequests())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var undeclared *UndeclaredResourceError
+	if !errors.As(results[0].Err, &undeclared) {
+		t.Fatalf("unexpected error: %v", results[0].Err)
+	}
+	if results[1].Err != nil || string(results[1].Resource) != `{"metadata":{"name":"settings"}}` {
+		t.Fatalf("unexpected result: %s, %v", results[1].Resource, results[1].Err)
+	}
+	mockWapcClient.AssertExpectations(t)
+
+	// no host call when all the resources are undeclared
+	results, err = GetResources(&capabilities.Host{Client: &mocks.MockWapcClient{}}, buildGetResourcesRequests()[:1])
+	if err != nil || !errors.As(results[0].Err, &undeclared) {
+		t.Fatalf("unexpected results: %v, %v", results, err)
+	}
+}
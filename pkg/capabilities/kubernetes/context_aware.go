@@ -0,0 +1,114 @@
+package kubernetes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ContextAwareResource is a kind of Kubernetes resources the policy reads
+// through the host.
+type ContextAwareResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// String returns the resource written as `apiVersion/kind`.
+func (r ContextAwareResource) String() string {
+	return r.APIVersion + "/" + r.Kind
+}
+
+// ResourceOf returns the ContextAwareResource of the given k8s-objects type,
+// e.g. `ResourceOf[corev1.Namespace]()`.
+func ResourceOf[T any, PT Object[T]]() ContextAwareResource {
+	apiVersion, kind := apiVersionAndKind[T, PT]()
+	return ContextAwareResource{APIVersion: apiVersion, Kind: kind}
+}
+
+// ContextAwareResources is the list of the resources a policy reads
+// through the host. It must match the `contextAwareResources` of the
+// metadata of the policy, otherwise the host refuses the lookups.
+type ContextAwareResources []ContextAwareResource
+
+// Allows tells whether the resources include the given kind.
+func (r ContextAwareResources) Allows(apiVersion, kind string) bool {
+	return slices.Contains(r, ContextAwareResource{APIVersion: apiVersion, Kind: kind})
+}
+
+// Metadata returns the `contextAwareResources` section of the metadata of
+// the policy, in YAML, sorted and without duplicates:
+//
+//	contextAwareResources:
+//	  - apiVersion: v1
+//	    kind: Namespace
+func (r ContextAwareResources) Metadata() string {
+	resources := slices.Clone(r)
+	slices.SortFunc(resources, func(a, b ContextAwareResource) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	resources = slices.Compact(resources)
+
+	if len(resources) == 0 {
+		return "contextAwareResources: []\n"
+	}
+	metadata := strings.Builder{}
+	metadata.WriteString("contextAwareResources:\n")
+	for _, resource := range resources {
+		fmt.Fprintf(&metadata, "  - apiVersion: %s\n    kind: %s\n", resource.APIVersion, resource.Kind)
+	}
+	return metadata.String()
+}
+
+// UndeclaredResourceError is returned in the native tests of a policy when
+// it looks up a resource missing from the declared context aware resources.
+type UndeclaredResourceError struct {
+	APIVersion string
+	Kind       string
+}
+
+func (e *UndeclaredResourceError) Error() string {
+	return fmt.Sprintf("%s/%s is not declared among the context aware resources of the policy, "+
+		"add it to DeclareContextAwareResources and to the contextAwareResources of the metadata", e.APIVersion, e.Kind)
+}
+
+// declaredResources are the resources declared by the policy, nil when the
+// policy doesn't declare them.
+//
+//nolint:gochecknoglobals // the resources are declared once by the policy
+var declaredResources ContextAwareResources
+
+// DeclareContextAwareResources declares the resources the policy reads
+// through the host, and returns them. It's meant to be invoked once, when
+// the policy is initialized:
+//
+//	var contextAwareResources = kubernetes.DeclareContextAwareResources(
+//		kubernetes.ResourceOf[corev1.Namespace](),
+//		kubernetes.ContextAwareResource{APIVersion: "example.com/v1", Kind: "Widget"},
+//	)
+//
+// When the policy is built as a native executable, like in its unit tests,
+// GetResource, ListResources, ListResourcesByNamespace and the functions
+// built on top of them return an *UndeclaredResourceError for the
+// resources that are not declared, like the host does at runtime. The
+// checks are disabled when no resource is declared.
+//
+// The declaration is stored in a package variable and lasts until the next
+// invocation: inside of the unit tests it applies to all the tests that
+// run afterwards. Tests changing it must restore it, e.g. by invoking
+// `DeclareContextAwareResources()` without resources in `t.Cleanup`.
+//
+// The Metadata method of the returned resources generates the matching
+// `contextAwareResources` section of the metadata.
+func DeclareContextAwareResources(resources ...ContextAwareResource) ContextAwareResources {
+	declaredResources = slices.Clone(resources)
+	return slices.Clone(resources)
+}
+
+// checkDeclaredResource returns an error when the policy declares its
+// context aware resources and the given one is not among them.
+func checkDeclaredResource(apiVersion, kind string) error {
+	if !enforceDeclaredResources || len(declaredResources) == 0 || declaredResources.Allows(apiVersion, kind) {
+		return nil
+	}
+	return &UndeclaredResourceError{APIVersion: apiVersion, Kind: kind}
+}
@@ -0,0 +1,9 @@
+//go:build !tinygo
+// +build !tinygo
+
+package kubernetes
+
+// enforceDeclaredResources tells whether the lookups of the resources that
+// are not declared fail. Native executables, like the unit tests of the
+// policies, enforce the declaration on behalf of the host.
+const enforceDeclaredResources = true
@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "github.com/kubewarden/k8s-objects/api/apps/v1"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+)
+
+// restoreDeclaredResources undoes the declarations done by a test.
+func restoreDeclaredResources(t *testing.T) {
+	saved := declaredResources
+	t.Cleanup(func() {
+		declaredResources = saved
+	})
+}
+
+func TestContextAwareResourcesMetadata(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		resources        ContextAwareResources
+		expectedMetadata string
+	}{
+		"Empty": {
+			expectedMetadata: "contextAwareResources: []\n",
+		},
+		"SortedWithoutDuplicates": {
+			resources: ContextAwareResources{
+				ResourceOf[corev1.Namespace](),
+				ResourceOf[appsv1.Deployment](),
+				{APIVersion: "v1", Kind: "Namespace"},
+			},
+			expectedMetadata: "contextAwareResources:\n" +
+				"  - apiVersion: apps/v1\n    kind: Deployment\n" +
+				"  - apiVersion: v1\n    kind: Namespace\n",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			if metadata := testCase.resources.Metadata(); metadata != testCase.expectedMetadata {
+				t.Fatalf("unexpected metadata:\n%s", metadata)
+			}
+		})
+	}
+}
+
+func TestContextAwareResourcesEnforcement(t *testing.T) {
+	restoreDeclaredResources(t)
+
+	resources := DeclareContextAwareResources(ResourceOf[corev1.Namespace]())
+	if !resources.Allows("v1", "Namespace") || resources.Allows("v1", "Secret") {
+		t.Fatalf("unexpected resources: %v", resources)
+	}
+
+	mockWapcClient := &mocks.MockWapcClient{}
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resource",
+			[]byte(`{"api_version":"v1","kind":"Namespace","name":"default","disable_cache":false}`)).
+		Return([]byte(`{"metadata":{"name":"default"}}`), nil).
+		Times(1)
+	host := &capabilities.Host{Client: mockWapcClient}
+
+	if _, err := GetResourceAs[corev1.Namespace](host, GetResourceRequest{Name: "default"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedError := "v1/Secret is not declared among the context aware resources of the policy, " +
+		"add it to DeclareContextAwareResources and to the contextAwareResources of the metadata"
+	namespace := "default"
+	for description, lookup := range map[string]func() error{
+		"GetResource": func() error {
+			_, err := GetResource(host, GetResourceRequest{APIVersion: "v1", Kind: "Secret", Name: "token", Namespace: &namespace})
+			return err
+		},
+		"ListResources": func() error {
+			_, err := ListAs[corev1.Secret](host, ListAllResourcesRequest{})
+			return err
+		},
+		"ListResourcesByNamespace": func() error {
+			_, err := ListResourcesByNamespace(host, ListResourcesByNamespaceRequest{APIVersion: "v1", Kind: "Secret", Namespace: namespace})
+			return err
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			err := lookup()
+			var undeclared *UndeclaredResourceError
+			if !errors.As(err, &undeclared) || err.Error() != expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+	// the undeclared lookups don't reach the host
+	mockWapcClient.AssertExpectations(t)
+}
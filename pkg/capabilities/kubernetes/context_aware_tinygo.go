@@ -0,0 +1,9 @@
+//go:build tinygo
+// +build tinygo
+
+package kubernetes
+
+// enforceDeclaredResources tells whether the lookups of the resources that
+// are not declared fail. Inside of WebAssembly the host enforces the
+// `contextAwareResources` of the metadata.
+const enforceDeclaredResources = false
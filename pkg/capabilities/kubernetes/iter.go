@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"iter"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+)
+
+// DefaultPageSize is the number of objects fetched with each host call by
+// the iterators, when the request doesn't set a `Limit`.
+const DefaultPageSize int64 = 100
+
+// IterResources iterates over the Kubernetes resources of the given
+// k8s-objects type defined inside of the cluster. The resources are fetched
+// lazily, one page at a time, and no more pages are fetched once the loop
+// stops:
+//
+//	for pod, err := range kubernetes.IterResources[corev1.Pod](host, kubernetes.ListAllResourcesRequest{}) {
+//		if err != nil {
+//			return err
+//		}
+//		if pod.Spec.HostNetwork {
+//			break
+//		}
+//	}
+//
+// The `APIVersion` and the `Kind` of the request are derived from the type,
+// the values set by the caller are ignored. The size of the pages is
+// `Limit`, DefaultPageSize by default. An error ends the iteration.
+func IterResources[T any, PT Object[T]](h *capabilities.Host, req ListAllResourcesRequest) iter.Seq2[T, error] {
+	req.APIVersion, req.Kind = apiVersionAndKind[T, PT]()
+	req.Limit = pageSize(req.Limit)
+
+	return iterPages[T](req.Continue, func(continueToken *string) ([]byte, error) {
+		page := req
+		page.Continue = continueToken
+		return ListResources(h, page)
+	})
+}
+
+// IterResourcesByNamespace iterates over the Kubernetes resources of the
+// given k8s-objects type defined inside of the namespace of the request,
+// like IterResources does.
+// Note: cannot be used for cluster-wide resources.
+func IterResourcesByNamespace[T any, PT Object[T]](
+	h *capabilities.Host,
+	req ListResourcesByNamespaceRequest,
+) iter.Seq2[T, error] {
+	req.APIVersion, req.Kind = apiVersionAndKind[T, PT]()
+	req.Limit = pageSize(req.Limit)
+
+	return iterPages[T](req.Continue, func(continueToken *string) ([]byte, error) {
+		page := req
+		page.Continue = continueToken
+		return ListResourcesByNamespace(h, page)
+	})
+}
+
+func pageSize(limit *int64) *int64 {
+	if limit != nil {
+		return limit
+	}
+	size := DefaultPageSize
+	return &size
+}
+
+// iterPages yields the items of the pages returned by fetch, starting from
+// the given continue token, until the last page is reached or the loop
+// stops.
+func iterPages[T any](continueToken *string, fetch func(*string) ([]byte, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		token := continueToken
+		for {
+			payload, err := fetch(token)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			items, next, err := decodePage[T](payload)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			token = &next
+		}
+	}
+}
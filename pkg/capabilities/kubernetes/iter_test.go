@@ -0,0 +1,110 @@
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+)
+
+func TestKubernetesIterResources(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "list_resources_all",
+			[]byte(`{"api_version":"v1","kind":"Pod","limit":2}`)).
+		Return([]byte(`{"metadata":{"continue":"page-2"},"items":[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}}]}`), nil).
+		Times(1)
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "list_resources_all",
+			[]byte(`{"api_version":"v1","kind":"Pod","limit":2,"continue":"page-2"}`)).
+		Return([]byte(`{"metadata":{},"items":[{"metadata":{"name":"c"}}]}`), nil).
+		Times(1)
+
+	limit := int64(2)
+	names := []string{}
+	for pod, err := range IterResources[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, ListAllResourcesRequest{
+		Limit: &limit,
+	}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, pod.Metadata.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, names); diff != "" {
+		t.Fatalf("unexpected pods:\n%s", diff)
+	}
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesIterResourcesStopsEarly(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "list_resources_by_namespace",
+			[]byte(`{"api_version":"v1","kind":"Pod","namespace":"default","limit":100}`)).
+		Return([]byte(`{"metadata":{"continue":"page-2"},"items":[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}}]}`), nil).
+		Times(1)
+
+	seen := 0
+	for pod, err := range IterResourcesByNamespace[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, ListResourcesByNamespaceRequest{
+		Namespace: "default",
+	}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		seen++
+		if pod.Metadata.Name == "a" {
+			break
+		}
+	}
+	if seen != 1 {
+		t.Fatalf("unexpected number of pods: %d", seen)
+	}
+	// the second page must not be fetched
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesIterResourcesErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		response      string
+		hostError     error
+		expectedError string
+	}{
+		"HostFailure": {
+			hostError:     errors.New("connection refused"),
+			expectedError: "connection refused",
+		},
+		"InvalidResponse": {
+			response:      `{"items":{}}`,
+			expectedError: "cannot unmarshall response object: json: cannot unmarshal object into Go struct field .items of type []v1.Pod",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			mockWapcClient := &mocks.MockWapcClient{}
+			continueToken := "page-3"
+			mockWapcClient.
+				EXPECT().
+				HostCall("kubewarden", "kubernetes", "list_resources_all",
+					[]byte(`{"api_version":"v1","kind":"Pod","limit":100,"continue":"page-3"}`)).
+				Return([]byte(testCase.response), testCase.hostError).
+				Times(1)
+
+			errs := []string{}
+			for _, err := range IterResources[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, ListAllResourcesRequest{
+				Continue: &continueToken,
+			}) {
+				if err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+			if diff := cmp.Diff([]string{testCase.expectedError}, errs); diff != "" {
+				t.Fatalf("unexpected errors:\n%s", diff)
+			}
+		})
+	}
+}
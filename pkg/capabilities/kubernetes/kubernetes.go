@@ -11,6 +11,10 @@ import (
 // the given namespace
 // Note: cannot be used for cluster-wide resources.
 func ListResourcesByNamespace(h *capabilities.Host, req ListResourcesByNamespaceRequest) ([]byte, error) {
+	if err := checkDeclaredResource(req.APIVersion, req.Kind); err != nil {
+		return []byte{}, err
+	}
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return []byte{}, fmt.Errorf("cannot serialize request object: %w", err)
@@ -28,6 +32,10 @@ func ListResourcesByNamespace(h *capabilities.Host, req ListResourcesByNamespace
 // ListResources gets all the Kubernetes resources defined inside of the cluster.
 // Note: this has be used for cluster-wide resources.
 func ListResources(h *capabilities.Host, req ListAllResourcesRequest) ([]byte, error) {
+	if err := checkDeclaredResource(req.APIVersion, req.Kind); err != nil {
+		return []byte{}, err
+	}
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return []byte{}, fmt.Errorf("cannot serialize request object: %w", err)
@@ -44,6 +52,10 @@ func ListResources(h *capabilities.Host, req ListAllResourcesRequest) ([]byte, e
 
 // GetResource gets a specific Kubernetes resource.
 func GetResource(h *capabilities.Host, req GetResourceRequest) ([]byte, error) {
+	if err := checkDeclaredResource(req.APIVersion, req.Kind); err != nil {
+		return []byte{}, err
+	}
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return []byte{}, fmt.Errorf("cannot serialize request object: %w", err)
@@ -0,0 +1,263 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/fields"
+	"github.com/kubewarden/policy-sdk-go/pkg/labels"
+)
+
+// ErrInvalidFieldMask is wrapped by the errors returned when the path of a
+// field mask doesn't exist inside of the object.
+var ErrInvalidFieldMask = errors.New("invalid field mask")
+
+// LabelSelectorBuilder builds the `LabelSelector` of the list requests:
+//
+//	selector, err := kubernetes.NewLabelSelector().
+//		Equals("app", "nginx").
+//		In("tier", "frontend", "backend").
+//		DoesNotExist("canary").
+//		Build()
+//
+// The requirements are validated with the rules of Kubernetes, and rendered
+// sorted by key, e.g. `app=nginx,!canary,tier in (backend,frontend)`.
+type LabelSelectorBuilder struct {
+	selector labels.Selector
+	errs     []error
+}
+
+// NewLabelSelector returns a builder of label selectors matching
+// everything.
+func NewLabelSelector() *LabelSelectorBuilder {
+	return &LabelSelectorBuilder{selector: labels.NewSelector()}
+}
+
+// Equals requires the label to have the value.
+func (b *LabelSelectorBuilder) Equals(key, value string) *LabelSelectorBuilder {
+	return b.add(key, labels.Equals, value)
+}
+
+// NotEquals requires the label to be missing, or to have another value.
+func (b *LabelSelectorBuilder) NotEquals(key, value string) *LabelSelectorBuilder {
+	return b.add(key, labels.NotEquals, value)
+}
+
+// In requires the label to have one of the values. The duplicated values
+// are ignored.
+func (b *LabelSelectorBuilder) In(key string, values ...string) *LabelSelectorBuilder {
+	return b.add(key, labels.In, uniqueValues(values)...)
+}
+
+// NotIn requires the label to be missing, or to have none of the values.
+// The duplicated values are ignored.
+func (b *LabelSelectorBuilder) NotIn(key string, values ...string) *LabelSelectorBuilder {
+	return b.add(key, labels.NotIn, uniqueValues(values)...)
+}
+
+// Exists requires the label to exist, regardless of its value.
+func (b *LabelSelectorBuilder) Exists(key string) *LabelSelectorBuilder {
+	return b.add(key, labels.Exists)
+}
+
+// DoesNotExist requires the label to be missing.
+func (b *LabelSelectorBuilder) DoesNotExist(key string) *LabelSelectorBuilder {
+	return b.add(key, labels.DoesNotExist)
+}
+
+func (b *LabelSelectorBuilder) add(key string, op labels.Operator, values ...string) *LabelSelectorBuilder {
+	requirement, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	b.selector = b.selector.Add(*requirement)
+	return b
+}
+
+// uniqueValues returns a sorted copy of the values, without duplicates.
+func uniqueValues(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return slices.Compact(slices.Sorted(slices.Values(values)))
+}
+
+// Build returns the selector to be used by the requests, which is empty
+// when the selector matches everything. It returns all the invalid
+// requirements as an error.
+func (b *LabelSelectorBuilder) Build() (string, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return "", err
+	}
+	return b.selector.String(), nil
+}
+
+// FieldSelectorBuilder builds the `FieldSelector` of the list requests:
+//
+//	selector, err := kubernetes.NewFieldSelector().
+//		Equals("status.phase", "Running").
+//		NotEquals("spec.nodeName", "").
+//		Build()
+//
+// The values are escaped, the terms are rendered in the order they are
+// added, e.g. `status.phase=Running,spec.nodeName!=`.
+type FieldSelectorBuilder struct {
+	selectors []fields.Selector
+	errs      []error
+}
+
+// NewFieldSelector returns a builder of field selectors matching
+// everything.
+func NewFieldSelector() *FieldSelectorBuilder {
+	return &FieldSelectorBuilder{}
+}
+
+// Equals requires the field to have the value.
+func (b *FieldSelectorBuilder) Equals(field, value string) *FieldSelectorBuilder {
+	if b.validateField(field) {
+		b.selectors = append(b.selectors, fields.OneTermEqualSelector(field, value))
+	}
+	return b
+}
+
+// NotEquals requires the field to have another value.
+func (b *FieldSelectorBuilder) NotEquals(field, value string) *FieldSelectorBuilder {
+	if b.validateField(field) {
+		b.selectors = append(b.selectors, fields.OneTermNotEqualSelector(field, value))
+	}
+	return b
+}
+
+// validateField rejects the fields that cannot be parsed back, because they
+// are empty or contain the special characters of the selectors.
+func (b *FieldSelectorBuilder) validateField(field string) bool {
+	if field == "" || strings.ContainsAny(field, `,=!\ `) {
+		b.errs = append(b.errs, fmt.Errorf("invalid field selector field %q", field))
+		return false
+	}
+	return true
+}
+
+// Build returns the selector to be used by the requests, which is empty
+// when the selector matches everything. It returns all the invalid fields
+// as an error.
+func (b *FieldSelectorBuilder) Build() (string, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return "", err
+	}
+	return fields.AndSelectors(b.selectors...).String(), nil
+}
+
+// FieldMasks validates the paths of the `FieldMasks` of the requests against
+// the given k8s-objects type, and returns them:
+//
+//	masks, err := kubernetes.FieldMasks[corev1.Pod]("metadata.name", "spec.containers.image")
+//
+// The paths use the JSON names of the fields, separated by dots. The arrays
+// are traversed implicitly: `spec.containers.image`, not
+// `spec.containers[].image`. The maps, like `metadata.labels`, can be
+// selected only as a whole.
+//
+// It returns all the invalid paths as an error wrapping
+// ErrInvalidFieldMask.
+func FieldMasks[T any, PT Object[T]](paths ...string) ([]string, error) {
+	_, kind := apiVersionAndKind[T, PT]()
+	objectType := reflect.TypeOf((*T)(nil)).Elem()
+
+	errs := []error{}
+	for _, path := range paths {
+		if err := validateFieldMask(objectType, kind, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// MustFieldMasks is like FieldMasks, but it panics when a path is invalid.
+// It's meant to declare the field masks as package variables, validated as
+// soon as the tests of the policy run.
+func MustFieldMasks[T any, PT Object[T]](paths ...string) []string {
+	masks, err := FieldMasks[T, PT](paths...)
+	if err != nil {
+		panic(err)
+	}
+	return masks
+}
+
+// validateFieldMask checks that the path leads to a field of the object.
+func validateFieldMask(objectType reflect.Type, kind, path string) error {
+	if path == "" {
+		return fmt.Errorf("%w: empty path", ErrInvalidFieldMask)
+	}
+
+	parent := kind
+	fieldType := objectType
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" || strings.ContainsAny(segment, "[]*") {
+			return fmt.Errorf("%w %q: %q is not a field name, the arrays are traversed implicitly",
+				ErrInvalidFieldMask, path, segment)
+		}
+
+		fieldType = dereference(fieldType)
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			field, found := jsonField(fieldType, segment)
+			if !found {
+				return fmt.Errorf("%w %q: %s has no field %q", ErrInvalidFieldMask, path, parent, segment)
+			}
+			fieldType = field.Type
+		case reflect.Interface:
+			// free-form value, like the custom resources embedded in other
+			// objects
+			return nil
+		case reflect.Map:
+			return fmt.Errorf("%w %q: %s is a map, it can be selected only as a whole", ErrInvalidFieldMask, path, parent)
+		default:
+			return fmt.Errorf("%w %q: %s has no fields", ErrInvalidFieldMask, path, parent)
+		}
+		parent = strings.TrimPrefix(parent+"."+segment, kind+".")
+	}
+	return nil
+}
+
+// dereference returns the type of the values behind the pointers and of
+// the items of the arrays.
+func dereference(fieldType reflect.Type) reflect.Type {
+	for {
+		switch fieldType.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			fieldType = fieldType.Elem()
+		default:
+			return fieldType
+		}
+	}
+}
+
+// jsonField looks for the field serialized with the given name.
+func jsonField(structType reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, _, _ := strings.Cut(tag, ",")
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		if fieldName == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,219 @@
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "github.com/kubewarden/k8s-objects/api/apps/v1"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+)
+
+func TestLabelSelectorBuilder(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		builder          *LabelSelectorBuilder
+		expectedSelector string
+		expectedError    string
+	}{
+		"Everything": {
+			builder:          NewLabelSelector(),
+			expectedSelector: "",
+		},
+		"EqualityBased": {
+			builder:          NewLabelSelector().Equals("app", "nginx").NotEquals("tier", "db"),
+			expectedSelector: "app=nginx,tier!=db",
+		},
+		"SetBased": {
+			builder: NewLabelSelector().
+				In("tier", "frontend", "backend", "frontend").
+				NotIn("env", "dev").
+				Exists("app.kubernetes.io/name").
+				DoesNotExist("canary"),
+			expectedSelector: "app.kubernetes.io/name,!canary,env notin (dev),tier in (backend,frontend)",
+		},
+		"InvalidValue": {
+			builder:       NewLabelSelector().Equals("app", "nginx,tier=db"),
+			expectedError: `values[0][app]: Invalid value: "nginx,tier=db": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		"EmptySet": {
+			builder:       NewLabelSelector().In("tier").Exists("app"),
+			expectedError: "values: Invalid value: []string(nil): for 'in', 'notin' operators, values set can't be empty",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			selector, err := testCase.builder.Build()
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if selector != testCase.expectedSelector {
+				t.Fatalf("unexpected selector: %q", selector)
+			}
+		})
+	}
+}
+
+func TestFieldSelectorBuilder(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		builder          *FieldSelectorBuilder
+		expectedSelector string
+		expectedError    string
+	}{
+		"Everything": {
+			builder:          NewFieldSelector(),
+			expectedSelector: "",
+		},
+		"Terms": {
+			builder:          NewFieldSelector().Equals("status.phase", "Running").NotEquals("spec.nodeName", ""),
+			expectedSelector: "status.phase=Running,spec.nodeName!=",
+		},
+		"Escaping": {
+			builder:          NewFieldSelector().Equals("metadata.name", `a,b=c\d`),
+			expectedSelector: `metadata.name=a\,b\=c\\d`,
+		},
+		"InvalidFields": {
+			builder:       NewFieldSelector().Equals("", "a").NotEquals("status.phase!", "b"),
+			expectedError: "invalid field selector field \"\"\ninvalid field selector field \"status.phase!\"",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			selector, err := testCase.builder.Build()
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if selector != testCase.expectedSelector {
+				t.Fatalf("unexpected selector: %q", selector)
+			}
+		})
+	}
+}
+
+func TestFieldMasks(t *testing.T) {
+	masks, err := FieldMasks[corev1.Pod](
+		"metadata.name",
+		"metadata.labels",
+		"spec.containers.image",
+		"spec.initContainers.securityContext.runAsUser",
+		"status.containerStatuses.state.running",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(masks) != 5 {
+		t.Fatalf("unexpected masks: %v", masks)
+	}
+
+	if _, err = FieldMasks[appsv1.Deployment]("spec.template.spec.containers.name"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFieldMasksErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		path          string
+		expectedError string
+	}{
+		"Empty": {
+			path:          "",
+			expectedError: "invalid field mask: empty path",
+		},
+		"ExplicitArray": {
+			path:          "spec.containers[].image",
+			expectedError: `invalid field mask "spec.containers[].image": "containers[]" is not a field name, the arrays are traversed implicitly`,
+		},
+		"EmptySegment": {
+			path:          "spec..containers",
+			expectedError: `invalid field mask "spec..containers": "" is not a field name, the arrays are traversed implicitly`,
+		},
+		"UnknownRootField": {
+			path:          "specs.containers",
+			expectedError: `invalid field mask "specs.containers": Pod has no field "specs"`,
+		},
+		"UnknownNestedField": {
+			path:          "spec.containers.images",
+			expectedError: `invalid field mask "spec.containers.images": spec.containers has no field "images"`,
+		},
+		"GoFieldName": {
+			path:          "spec.Containers",
+			expectedError: `invalid field mask "spec.Containers": spec has no field "Containers"`,
+		},
+		"MapKey": {
+			path:          "metadata.labels.app",
+			expectedError: `invalid field mask "metadata.labels.app": metadata.labels is a map, it can be selected only as a whole`,
+		},
+		"Leaf": {
+			path:          "spec.nodeName.length",
+			expectedError: `invalid field mask "spec.nodeName.length": spec.nodeName has no fields`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := FieldMasks[corev1.Pod](testCase.path)
+			if !errors.Is(err, ErrInvalidFieldMask) || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MustFieldMasks should panic")
+		}
+	}()
+	MustFieldMasks[corev1.Pod]("metadata.name", "spec.container")
+}
+
+func TestKubernetesBuildersPayload(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+
+	expectedInputPayload := `{"api_version":"v1","kind":"Pod","namespace":"default",` +
+		`"label_selector":"app.kubernetes.io/name=nginx,tier in (backend,frontend)",` +
+		`"field_selector":"status.phase=Running,spec.nodeName!=",` +
+		`"field_masks":["metadata.name","spec.containers.image"]}`
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "list_resources_by_namespace", []byte(expectedInputPayload)).
+		Return([]byte(`{"items":[]}`), nil).
+		Times(1)
+
+	labelSelector, err := NewLabelSelector().
+		In("tier", "frontend", "backend").
+		Equals("app.kubernetes.io/name", "nginx").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fieldSelector, err := NewFieldSelector().
+		Equals("status.phase", "Running").
+		NotEquals("spec.nodeName", "").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pods, err := ListByNamespaceAs[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, ListResourcesByNamespaceRequest{
+		Namespace:     "default",
+		LabelSelector: &labelSelector,
+		FieldSelector: &fieldSelector,
+		FieldMasks:    MustFieldMasks[corev1.Pod]("metadata.name", "spec.containers.image"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]corev1.Pod{}, pods); diff != "" {
+		t.Fatalf("unexpected pods:\n%s", diff)
+	}
+	mockWapcClient.AssertExpectations(t)
+}
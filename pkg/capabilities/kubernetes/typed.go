@@ -0,0 +1,153 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/runtime/schema"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+)
+
+// notFoundSuffix ends the messages of the Kubernetes API errors returned
+// when the resource doesn't exist. The host forwards them as
+// `<message>: <reason>`, e.g. `ApiError: pods "nginx" not found: NotFound`.
+const notFoundSuffix = ": NotFound"
+
+// Object is implemented by the pointers to the k8s-objects types, like
+// `*corev1.Pod`, through their `GroupVersionKind` method.
+type Object[T any] interface {
+	*T
+	GroupVersionKind() schema.GroupVersionKind
+}
+
+// NotFoundError is returned by GetResourceAs when the requested resource
+// doesn't exist. Any other error returned by the host, like a transport or
+// an authorization failure, is returned as it is.
+type NotFoundError struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	// Namespace is empty for cluster-wide resources
+	Namespace string
+	// Err is the error returned by the host
+	Err error
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Namespace == "" {
+		return fmt.Sprintf("%s %s %s not found", e.APIVersion, e.Kind, e.Name)
+	}
+	return fmt.Sprintf("%s %s %s/%s not found", e.APIVersion, e.Kind, e.Namespace, e.Name)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// apiVersionAndKind returns the `apiVersion` and the `kind` of the type.
+func apiVersionAndKind[T any, PT Object[T]]() (string, string) {
+	return PT(new(T)).GroupVersionKind().ToAPIVersionAndKind()
+}
+
+// isNotFound tells whether the host error reports a missing resource.
+func isNotFound(err error) bool {
+	return strings.HasSuffix(strings.TrimSpace(err.Error()), notFoundSuffix)
+}
+
+// wrapNotFound returns a *NotFoundError when the host error reports that
+// the requested resource doesn't exist, otherwise the error itself.
+func wrapNotFound(req GetResourceRequest, err error) error {
+	if !isNotFound(err) {
+		return err
+	}
+	notFound := &NotFoundError{APIVersion: req.APIVersion, Kind: req.Kind, Name: req.Name, Err: err}
+	if req.Namespace != nil {
+		notFound.Namespace = *req.Namespace
+	}
+	return notFound
+}
+
+// GetResourceAs gets a specific Kubernetes resource and decodes it into the
+// given k8s-objects type, e.g.:
+//
+//	pod, err := kubernetes.GetResourceAs[corev1.Pod](host, kubernetes.GetResourceRequest{
+//		Name:      "nginx",
+//		Namespace: &namespace,
+//	})
+//
+// The `APIVersion` and the `Kind` of the request are derived from the type,
+// the values set by the caller are ignored. It returns a *NotFoundError when
+// the resource doesn't exist.
+func GetResourceAs[T any, PT Object[T]](h *capabilities.Host, req GetResourceRequest) (*T, error) {
+	req.APIVersion, req.Kind = apiVersionAndKind[T, PT]()
+
+	responsePayload, err := GetResource(h, req)
+	if err != nil {
+		return nil, wrapNotFound(req, err)
+	}
+
+	resource := new(T)
+	if err = json.Unmarshal(responsePayload, resource); err != nil {
+		return nil, fmt.Errorf("cannot unmarshall response object: %w", err)
+	}
+	return resource, nil
+}
+
+// ListAs gets all the Kubernetes resources of the given k8s-objects type
+// defined inside of the cluster, e.g.:
+//
+//	namespaces, err := kubernetes.ListAs[corev1.Namespace](host, kubernetes.ListAllResourcesRequest{})
+//
+// The `APIVersion` and the `Kind` of the request are derived from the type,
+// the values set by the caller are ignored.
+func ListAs[T any, PT Object[T]](h *capabilities.Host, req ListAllResourcesRequest) ([]T, error) {
+	req.APIVersion, req.Kind = apiVersionAndKind[T, PT]()
+
+	responsePayload, err := ListResources(h, req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeList[T](responsePayload)
+}
+
+// ListByNamespaceAs gets all the Kubernetes resources of the given
+// k8s-objects type defined inside of the namespace of the request.
+//
+// The `APIVersion` and the `Kind` of the request are derived from the type,
+// the values set by the caller are ignored.
+// Note: cannot be used for cluster-wide resources.
+func ListByNamespaceAs[T any, PT Object[T]](h *capabilities.Host, req ListResourcesByNamespaceRequest) ([]T, error) {
+	req.APIVersion, req.Kind = apiVersionAndKind[T, PT]()
+
+	responsePayload, err := ListResourcesByNamespace(h, req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeList[T](responsePayload)
+}
+
+// decodeList decodes the items of the list returned by the host, like a
+// `PodList`.
+func decodeList[T any](payload []byte) ([]T, error) {
+	items, _, err := decodePage[T](payload)
+	return items, err
+}
+
+// decodePage decodes the items of the list returned by the host, together
+// with the token of the next page, which is empty on the last page.
+func decodePage[T any](payload []byte) ([]T, string, error) {
+	list := struct {
+		Metadata struct {
+			Continue string `json:"continue"`
+		} `json:"metadata"`
+		Items []T `json:"items"`
+	}{}
+	if err := json.Unmarshal(payload, &list); err != nil {
+		return nil, "", fmt.Errorf("cannot unmarshall response object: %w", err)
+	}
+	if list.Items == nil {
+		return []T{}, list.Metadata.Continue, nil
+	}
+	return list.Items, list.Metadata.Continue, nil
+}
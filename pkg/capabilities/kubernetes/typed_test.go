@@ -0,0 +1,178 @@
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "github.com/kubewarden/k8s-objects/api/apps/v1"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestKubernetesGetResourceAs(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+
+	expectedInputPayload := `{"api_version":"apps/v1","kind":"Deployment","name":"nginx","namespace":"default","disable_cache":false}`
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "get_resource", []byte(expectedInputPayload)).
+		Return([]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"nginx"}}`), nil).
+		Times(1)
+
+	host := &capabilities.Host{
+		Client: mockWapcClient,
+	}
+	namespace := "default"
+
+	deployment, err := GetResourceAs[appsv1.Deployment](host, GetResourceRequest{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       "nginx",
+		Namespace:  &namespace,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deployment.Metadata == nil || deployment.Metadata.Name != "nginx" {
+		t.Fatalf("unexpected deployment: %+v", deployment)
+	}
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesGetResourceAsErrors(t *testing.T) {
+	namespace := "default"
+	for description, testCase := range map[string]struct {
+		namespace        *string
+		hostError        error
+		response         string
+		expectedNotFound bool
+		expectedError    string
+	}{
+		"NotFound": {
+			namespace:        &namespace,
+			hostError:        errors.New(`ApiError: pods "nginx" not found: NotFound`),
+			expectedNotFound: true,
+			expectedError:    "v1 Pod default/nginx not found",
+		},
+		"ClusterWideNotFound": {
+			hostError:        errors.New(`ApiError: pods "nginx" not found: NotFound`),
+			expectedNotFound: true,
+			expectedError:    "v1 Pod nginx not found",
+		},
+		"NotFoundInMessage": {
+			namespace:     &namespace,
+			hostError:     errors.New(`ApiError: pods "NotFound" is forbidden: Forbidden`),
+			expectedError: `ApiError: pods "NotFound" is forbidden: Forbidden`,
+		},
+		"HostFailure": {
+			namespace:     &namespace,
+			hostError:     errors.New("connection refused"),
+			expectedError: "connection refused",
+		},
+		"InvalidResponse": {
+			namespace:     &namespace,
+			response:      `[]`,
+			expectedError: "cannot unmarshall response object: json: cannot unmarshal array into Go value of type v1.Pod",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			mockWapcClient := &mocks.MockWapcClient{}
+			mockWapcClient.
+				EXPECT().
+				HostCall("kubewarden", "kubernetes", "get_resource", mock.Anything).
+				Return([]byte(testCase.response), testCase.hostError).
+				Times(1)
+
+			_, err := GetResourceAs[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, GetResourceRequest{
+				Name:      "nginx",
+				Namespace: testCase.namespace,
+			})
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var notFound *NotFoundError
+			if errors.As(err, &notFound) != testCase.expectedNotFound {
+				t.Fatalf("unexpected error type: %T", err)
+			}
+			if testCase.hostError != nil && !errors.Is(err, testCase.hostError) {
+				t.Fatalf("the host error should be wrapped: %v", err)
+			}
+		})
+	}
+}
+
+func TestKubernetesListAs(t *testing.T) {
+	mockWapcClient := &mocks.MockWapcClient{}
+
+	expectedInputPayload := `{"api_version":"v1","kind":"Namespace","label_selector":"team=a"}`
+	mockWapcClient.
+		EXPECT().
+		HostCall("kubewarden", "kubernetes", "list_resources_all", []byte(expectedInputPayload)).
+		Return([]byte(`{"apiVersion":"v1","kind":"NamespaceList","items":[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}}]}`), nil).
+		Times(1)
+
+	labelSelector := "team=a"
+	namespaces, err := ListAs[corev1.Namespace](&capabilities.Host{Client: mockWapcClient}, ListAllResourcesRequest{
+		LabelSelector: &labelSelector,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, namespace := range namespaces {
+		names = append(names, namespace.Metadata.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, names); diff != "" {
+		t.Fatalf("unexpected namespaces:\n%s", diff)
+	}
+	mockWapcClient.AssertExpectations(t)
+}
+
+func TestKubernetesListByNamespaceAs(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		response      string
+		expectedPods  int
+		expectedError string
+	}{
+		"Pods": {
+			response:     `{"apiVersion":"v1","kind":"PodList","items":[{"metadata":{"name":"nginx"}}]}`,
+			expectedPods: 1,
+		},
+		"EmptyList": {
+			response: `{"apiVersion":"v1","kind":"PodList","items":null}`,
+		},
+		"InvalidResponse": {
+			response:      `{"items":{}}`,
+			expectedError: "cannot unmarshall response object: json: cannot unmarshal object into Go struct field .items of type []v1.Pod",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			mockWapcClient := &mocks.MockWapcClient{}
+			expectedInputPayload := `{"api_version":"v1","kind":"Pod","namespace":"default"}`
+			mockWapcClient.
+				EXPECT().
+				HostCall("kubewarden", "kubernetes", "list_resources_by_namespace", []byte(expectedInputPayload)).
+				Return([]byte(testCase.response), nil).
+				Times(1)
+
+			pods, err := ListByNamespaceAs[corev1.Pod](&capabilities.Host{Client: mockWapcClient}, ListResourcesByNamespaceRequest{
+				Namespace: "default",
+			})
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pods == nil || len(pods) != testCase.expectedPods {
+				t.Fatalf("unexpected pods: %+v", pods)
+			}
+		})
+	}
+}
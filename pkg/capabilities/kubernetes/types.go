@@ -34,6 +34,13 @@ type ListResourcesByNamespaceRequest struct {
 	//     "spec.containers.image",
 	//   }
 	FieldMasks []string `json:"field_masks,omitempty"`
+	// The maximum number of objects returned by the host in one response.
+	// When more objects are available, the response has a
+	// `metadata.continue` token. Defaults to all the objects if omitted
+	Limit *int64 `json:"limit,omitempty"`
+	// The `metadata.continue` token of the previous response, used to fetch
+	// the next page of objects
+	Continue *string `json:"continue,omitempty"`
 }
 
 // ListAllResourcesRequest represents a set of parameters used by the `list_all_resources` function.
@@ -68,6 +75,13 @@ type ListAllResourcesRequest struct {
 	//     "spec.containers.image",
 	//   }
 	FieldMasks []string `json:"field_masks,omitempty"`
+	// The maximum number of objects returned by the host in one response.
+	// When more objects are available, the response has a
+	// `metadata.continue` token. Defaults to all the objects if omitted
+	Limit *int64 `json:"limit,omitempty"`
+	// The `metadata.continue` token of the previous response, used to fetch
+	// the next page of objects
+	Continue *string `json:"continue,omitempty"`
 }
 
 // GetResourceRequest represents a set of parameters used by the `get_resource` function.
@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ErrPlatformNotFound is returned when the image doesn't support the
+// requested platform.
+var ErrPlatformNotFound = errors.New("no image for the platform")
+
+// SelectPlatform returns the descriptor of the manifest of the index built
+// for the given platform, e.g. `linux`, `arm64` and `v8`. The architecture
+// aliases used by the kernels, like `x86_64` and `aarch64`, are accepted.
+//
+// The variant is optional: when it's empty, the default variant of the
+// architecture is selected, `v7` for `arm` and `v8` for `arm64`, while any
+// variant is accepted for the other architectures.
+//
+// It returns an error wrapping ErrPlatformNotFound when no manifest matches
+// the platform.
+func SelectPlatform(index *specs.Index, os, arch, variant string) (*specs.Descriptor, error) {
+	if index == nil {
+		return nil, errors.New("the image index is empty")
+	}
+
+	for i, descriptor := range index.Manifests {
+		if MatchesPlatform(descriptor.Platform, os, arch, variant) {
+			return &index.Manifests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s", ErrPlatformNotFound, FormatPlatform(os, arch, variant))
+}
+
+// MatchesPlatform tells whether the platform is the given one, using the
+// same rules of SelectPlatform.
+func MatchesPlatform(platform *specs.Platform, os, arch, variant string) bool {
+	if platform == nil || platform.OS != os {
+		return false
+	}
+	arch = normalizeArchitecture(arch)
+	if normalizeArchitecture(platform.Architecture) != arch {
+		return false
+	}
+
+	requested := normalizeVariant(arch, variant)
+	return requested == "" || normalizeVariant(arch, platform.Variant) == requested
+}
+
+// FormatPlatform returns the platform written as `os/arch[/variant]`, like
+// `linux/arm64/v8`.
+func FormatPlatform(os, arch, variant string) string {
+	formatted := os + "/" + arch
+	if variant != "" {
+		formatted += "/" + variant
+	}
+	return formatted
+}
+
+func normalizeArchitecture(arch string) string {
+	switch arch {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	case "armhf":
+		return "arm"
+	default:
+		return arch
+	}
+}
+
+// normalizeVariant returns the variant, using the default variant of the
+// architecture when it's empty.
+func normalizeVariant(arch, variant string) string {
+	switch {
+	case arch == "arm64" && (variant == "" || variant == "8"):
+		return "v8"
+	case arch == "arm" && (variant == "" || variant == "7"):
+		return "v7"
+	case arch == "arm" && (variant == "5" || variant == "6"):
+		return "v" + variant
+	default:
+		return variant
+	}
+}
@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func buildMultiPlatformIndex() *specs.Index {
+	descriptor := func(os, arch, variant string) specs.Descriptor {
+		return specs.Descriptor{
+			MediaType: specs.MediaTypeImageManifest,
+			Digest:    digest.FromString(os + arch + variant),
+			Platform:  &specs.Platform{OS: os, Architecture: arch, Variant: variant},
+		}
+	}
+	return &specs.Index{
+		MediaType: specs.MediaTypeImageIndex,
+		Manifests: []specs.Descriptor{
+			{MediaType: specs.MediaTypeImageManifest, Digest: digest.FromString("no platform")},
+			descriptor("linux", "amd64", ""),
+			descriptor("linux", "arm", "v6"),
+			descriptor("linux", "arm", "v7"),
+			descriptor("linux", "arm64", "v8"),
+			descriptor("windows", "amd64", ""),
+			descriptor("unknown", "unknown", ""),
+		},
+	}
+}
+
+func TestSelectPlatform(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		os, arch, variant string
+		expected          string
+	}{
+		"Amd64":               {os: "linux", arch: "amd64", expected: "linuxamd64"},
+		"ArchitectureAlias":   {os: "linux", arch: "x86_64", expected: "linuxamd64"},
+		"Arm64DefaultVariant": {os: "linux", arch: "arm64", expected: "linuxarm64v8"},
+		"Arm64Alias":          {os: "linux", arch: "aarch64", variant: "8", expected: "linuxarm64v8"},
+		"ArmDefaultVariant":   {os: "linux", arch: "arm", expected: "linuxarmv7"},
+		"ArmVariant":          {os: "linux", arch: "arm", variant: "v6", expected: "linuxarmv6"},
+		"Windows":             {os: "windows", arch: "amd64", expected: "windowsamd64"},
+	} {
+		t.Run(description, func(t *testing.T) {
+			descriptor, err := SelectPlatform(buildMultiPlatformIndex(), testCase.os, testCase.arch, testCase.variant)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if descriptor.Digest != digest.FromString(testCase.expected) {
+				t.Fatalf("unexpected manifest: %+v", descriptor.Platform)
+			}
+		})
+	}
+}
+
+func TestSelectPlatformNotFound(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		os, arch, variant string
+		expectedError     string
+	}{
+		"Architecture": {os: "linux", arch: "s390x", expectedError: "no image for the platform linux/s390x"},
+		"Variant":      {os: "linux", arch: "arm", variant: "v5", expectedError: "no image for the platform linux/arm/v5"},
+		"OS":           {os: "darwin", arch: "arm64", expectedError: "no image for the platform darwin/arm64"},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := SelectPlatform(buildMultiPlatformIndex(), testCase.os, testCase.arch, testCase.variant)
+			if !errors.Is(err, ErrPlatformNotFound) || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if _, err := SelectPlatform(nil, "linux", "amd64", ""); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
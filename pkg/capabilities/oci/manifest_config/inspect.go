@@ -0,0 +1,349 @@
+package manifest_config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// rootUser is the name of the user whose UID is 0.
+	rootUser = "root"
+	// defaultPortProtocol is the protocol of the exposed ports that don't
+	// declare one.
+	defaultPortProtocol = "tcp"
+	// maxPort is the highest port number.
+	maxPort = 65535
+)
+
+// InspectOption changes the behavior of Inspect.
+type InspectOption func(*ImageInspection)
+
+// WithRunAsUser overrides the user of the image, like the `runAsUser` field
+// of the security context does. A nil UID leaves the user of the image
+// untouched.
+//
+// The k8s-objects types cannot tell an explicit `runAsUser: 0` from a
+// missing value, see WithSecurityContexts.
+func WithRunAsUser(uid *int64) InspectOption {
+	return func(i *ImageInspection) {
+		if uid != nil {
+			value := *uid
+			i.user.Name = ""
+			i.user.UID = &value
+		}
+	}
+}
+
+// WithRunAsGroup overrides the group of the image, like the `runAsGroup`
+// field of the security context does. A nil GID leaves the group of the
+// image untouched.
+func WithRunAsGroup(gid *int64) InspectOption {
+	return func(i *ImageInspection) {
+		if gid != nil {
+			value := *gid
+			i.user.Group = ""
+			i.user.GID = &value
+		}
+	}
+}
+
+// runAsFields are the fields of the security contexts overriding the user
+// of the image.
+type runAsFields struct {
+	RunAsUser  *int64 `json:"runAsUser"`
+	RunAsGroup *int64 `json:"runAsGroup"`
+}
+
+// WithSecurityContexts overrides the user and the group of the image with
+// the `runAsUser` and `runAsGroup` fields of the security contexts of the
+// pod and of the container, the latter taking precedence. podSpec is the
+// raw PodSpec, e.g. the one at `Workload.PodSpecPath` of the object, and
+// containerPath is the path of the container inside of it, like
+// `ContainerRef.Path`.
+//
+// The fields are read from the raw JSON because the k8s-objects types cannot
+// tell an explicit `runAsUser: 0` from a missing value.
+func WithSecurityContexts(podSpec []byte, containerPath rawjson.Path) (InspectOption, error) {
+	pod, err := readRunAsFields(podSpec, rawjson.Path{}.Key("securityContext"))
+	if err != nil {
+		return nil, err
+	}
+	container, err := readRunAsFields(podSpec, containerPath.Key("securityContext"))
+	if err != nil {
+		return nil, err
+	}
+	if container.RunAsUser != nil {
+		pod.RunAsUser = container.RunAsUser
+	}
+	if container.RunAsGroup != nil {
+		pod.RunAsGroup = container.RunAsGroup
+	}
+	return func(i *ImageInspection) {
+		WithRunAsUser(pod.RunAsUser)(i)
+		WithRunAsGroup(pod.RunAsGroup)(i)
+	}, nil
+}
+
+func readRunAsFields(podSpec []byte, path rawjson.Path) (runAsFields, error) {
+	fields := runAsFields{}
+	raw, found, err := rawjson.Get(podSpec, path)
+	if err != nil {
+		return fields, err
+	}
+	if !found {
+		return fields, nil
+	}
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return fields, fmt.Errorf("invalid security context %s: %w", path.String(), err)
+	}
+	return fields, nil
+}
+
+// ImageUser is the user running the processes of a container.
+type ImageUser struct {
+	// Name is the name of the user, it's empty when the user is given as a
+	// UID
+	Name string
+	// UID is the user ID, it's nil when the user is given by name and the
+	// name cannot be resolved without inspecting the filesystem of the image
+	UID *int64
+	// Group is the name of the group, it's empty when the group is given as a
+	// GID or it's not given
+	Group string
+	// GID is the group ID, it's nil when it's not known
+	GID *int64
+}
+
+// String returns the user written as `user[:group]`, like the `USER`
+// instruction of the Dockerfiles.
+func (u ImageUser) String() string {
+	user := u.Name
+	if u.UID != nil {
+		user = strconv.FormatInt(*u.UID, 10)
+	}
+	group := u.Group
+	if u.GID != nil {
+		group = strconv.FormatInt(*u.GID, 10)
+	}
+	if group == "" {
+		return user
+	}
+	return user + ":" + group
+}
+
+// ExposedPort is a port exposed by the image.
+type ExposedPort struct {
+	Port int
+	// Protocol is the lowercase name of the protocol, `tcp` by default
+	Protocol string
+}
+
+// String returns the port written as `port/protocol`.
+func (p ExposedPort) String() string {
+	return strconv.Itoa(p.Port) + "/" + p.Protocol
+}
+
+// ImageInspection gives access to the execution parameters of an image.
+type ImageInspection struct {
+	config specs.ImageConfig
+	user   ImageUser
+	ports  []ExposedPort
+}
+
+// Inspect returns the execution parameters of the image, taking into
+// account the overrides given with the options.
+func (r *OciImageManifestAndConfigResponse) Inspect(opts ...InspectOption) (*ImageInspection, error) {
+	if r.ImageConfig == nil {
+		return nil, errors.New("the response doesn't have the configuration of the image")
+	}
+	return Inspect(r.ImageConfig.Config, opts...)
+}
+
+// Inspect returns the execution parameters of the given configuration,
+// taking into account the overrides given with the options.
+func Inspect(config specs.ImageConfig, opts ...InspectOption) (*ImageInspection, error) {
+	user, err := parseUser(config.User)
+	if err != nil {
+		return nil, err
+	}
+	ports, err := parseExposedPorts(config.ExposedPorts)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &ImageInspection{config: config, user: user, ports: ports}
+	for _, opt := range opts {
+		opt(inspection)
+	}
+	return inspection, nil
+}
+
+// parseUser parses the user of the image, written as `user[:group]`, where
+// both the user and the group can be a name or an ID. Images without a user
+// run as root.
+func parseUser(value string) (ImageUser, error) {
+	name, group, _ := strings.Cut(value, ":")
+	user := ImageUser{}
+
+	switch {
+	case name == "" || name == rootUser:
+		user.Name = rootUser
+		user.UID = new(int64)
+	default:
+		uid, isID, err := parseID(name)
+		if err != nil {
+			return ImageUser{}, fmt.Errorf("invalid user %q: %w", value, err)
+		}
+		if isID {
+			user.UID = &uid
+		} else {
+			user.Name = name
+		}
+	}
+
+	switch {
+	case group == "":
+	case group == rootUser:
+		user.Group = rootUser
+		user.GID = new(int64)
+	default:
+		gid, isID, err := parseID(group)
+		if err != nil {
+			return ImageUser{}, fmt.Errorf("invalid user %q: %w", value, err)
+		}
+		if isID {
+			user.GID = &gid
+		} else {
+			user.Group = group
+		}
+	}
+	return user, nil
+}
+
+// parseID parses a UID or a GID, and tells whether the value is numeric.
+func parseID(value string) (int64, bool, error) {
+	if strings.TrimLeft(value, "0123456789") != "" {
+		return 0, false, nil
+	}
+	// UIDs and GIDs are unsigned 32 bit integers
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, true, err
+	}
+	return int64(id), true, nil //nolint:gosec // the value has at most 32 bits
+}
+
+func parseExposedPorts(exposedPorts map[string]struct{}) ([]ExposedPort, error) {
+	ports := make([]ExposedPort, 0, len(exposedPorts))
+	for value := range exposedPorts {
+		port, protocol, found := strings.Cut(value, "/")
+		if !found {
+			protocol = defaultPortProtocol
+		}
+		number, err := strconv.Atoi(port)
+		if err != nil || number <= 0 || number > maxPort || protocol == "" {
+			return nil, fmt.Errorf("invalid exposed port %q", value)
+		}
+		ports = append(ports, ExposedPort{Port: number, Protocol: strings.ToLower(protocol)})
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+	return ports, nil
+}
+
+// User returns the user running the processes of the container, taking
+// into account the overrides.
+func (i *ImageInspection) User() ImageUser {
+	return i.user
+}
+
+// RunsAsRoot tells whether the processes of the container run as root:
+// either the image doesn't declare a user, or its UID is 0.
+//
+// Users given by name, other than `root`, are not considered root, even if
+// their UID cannot be verified: use `User().UID` to reject them, like the
+// kubelet does when `runAsNonRoot` is set.
+func (i *ImageInspection) RunsAsRoot() bool {
+	return i.user.UID != nil && *i.user.UID == 0
+}
+
+// Entrypoint returns the command executed when the container starts.
+func (i *ImageInspection) Entrypoint() []string {
+	return i.config.Entrypoint
+}
+
+// Cmd returns the default arguments of the entrypoint.
+func (i *ImageInspection) Cmd() []string {
+	return i.config.Cmd
+}
+
+// WorkingDir returns the working directory of the entrypoint.
+func (i *ImageInspection) WorkingDir() string {
+	return i.config.WorkingDir
+}
+
+// ExposedPorts returns the ports exposed by the image, sorted by number.
+func (i *ImageInspection) ExposedPorts() []ExposedPort {
+	return i.ports
+}
+
+// ExposesPort tells whether the image exposes the port using the protocol,
+// which is case insensitive.
+func (i *ImageInspection) ExposesPort(port int, protocol string) bool {
+	for _, exposed := range i.ports {
+		if exposed.Port == port && exposed.Protocol == strings.ToLower(protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns the environment variables of the image. When a variable is
+// defined more than once, the last definition wins.
+func (i *ImageInspection) Env() map[string]string {
+	env := make(map[string]string, len(i.config.Env))
+	for _, variable := range i.config.Env {
+		name, value, _ := strings.Cut(variable, "=")
+		env[name] = value
+	}
+	return env
+}
+
+// LookupEnv returns the value of the environment variable, and tells
+// whether the variable is defined.
+func (i *ImageInspection) LookupEnv(name string) (string, bool) {
+	value, found := i.Env()[name]
+	return value, found
+}
+
+// Labels returns the labels of the image, like
+// `org.opencontainers.image.source`.
+func (i *ImageInspection) Labels() map[string]string {
+	return maps.Clone(i.config.Labels)
+}
+
+// HasLabel tells whether the image has the label, regardless of its value.
+func (i *ImageInspection) HasLabel(key string) bool {
+	_, found := i.config.Labels[key]
+	return found
+}
+
+// Label returns the value of the label, and tells whether the image has
+// the label.
+func (i *ImageInspection) Label(key string) (string, bool) {
+	value, found := i.config.Labels[key]
+	return value, found
+}
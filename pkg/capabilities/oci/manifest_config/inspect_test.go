@@ -0,0 +1,245 @@
+package manifest_config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func int64Pointer(value int64) *int64 {
+	return &value
+}
+
+func TestInspectUser(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		user               string
+		opts               []InspectOption
+		expectedUser       string
+		expectedRunsAsRoot bool
+		expectedResolved   bool
+	}{
+		"NoUser": {
+			user:               "",
+			expectedUser:       "0",
+			expectedRunsAsRoot: true,
+			expectedResolved:   true,
+		},
+		"RootByName": {
+			user:               "root:root",
+			expectedUser:       "0:0",
+			expectedRunsAsRoot: true,
+			expectedResolved:   true,
+		},
+		"NumericUser": {
+			user:             "1000:3000",
+			expectedUser:     "1000:3000",
+			expectedResolved: true,
+		},
+		"NumericRoot": {
+			user:               "0",
+			expectedUser:       "0",
+			expectedRunsAsRoot: true,
+			expectedResolved:   true,
+		},
+		"NamedUser": {
+			user:         "nginx:nginx",
+			expectedUser: "nginx:nginx",
+		},
+		"RunAsUser": {
+			user:             "",
+			opts:             []InspectOption{WithRunAsUser(int64Pointer(1000)), WithRunAsGroup(int64Pointer(2000))},
+			expectedUser:     "1000:2000",
+			expectedResolved: true,
+		},
+		"RunAsRoot": {
+			user:               "nginx",
+			opts:               []InspectOption{WithRunAsUser(int64Pointer(0))},
+			expectedUser:       "0",
+			expectedRunsAsRoot: true,
+			expectedResolved:   true,
+		},
+		"NilOverride": {
+			user:         "nginx",
+			opts:         []InspectOption{WithRunAsUser(nil), WithRunAsGroup(nil)},
+			expectedUser: "nginx",
+		},
+		"RunAsRootGroup": {
+			user:               "nginx:nginx",
+			opts:               []InspectOption{WithRunAsUser(int64Pointer(0)), WithRunAsGroup(int64Pointer(0))},
+			expectedUser:       "0:0",
+			expectedRunsAsRoot: true,
+			expectedResolved:   true,
+		},
+		"LargeIDs": {
+			user:             "4294967294:2147483648",
+			expectedUser:     "4294967294:2147483648",
+			expectedResolved: true,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			inspection, err := Inspect(specs.ImageConfig{User: testCase.user}, testCase.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			user := inspection.User()
+			if user.String() != testCase.expectedUser {
+				t.Fatalf("unexpected user: %s", user.String())
+			}
+			if inspection.RunsAsRoot() != testCase.expectedRunsAsRoot {
+				t.Fatalf("expected RunsAsRoot to be %t", testCase.expectedRunsAsRoot)
+			}
+			if (user.UID != nil) != testCase.expectedResolved {
+				t.Fatalf("unexpected UID: %v", user.UID)
+			}
+		})
+	}
+}
+
+func TestInspectSecurityContexts(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		podSpec            string
+		containerPath      string
+		expectedUser       string
+		expectedRunsAsRoot bool
+	}{
+		"NoSecurityContexts": {
+			podSpec:       `{"containers": [{"image": "nginx"}]}`,
+			containerPath: "containers[0]",
+			expectedUser:  "nginx:nginx",
+		},
+		"PodRoot": {
+			podSpec:            `{"securityContext": {"runAsUser": 0}, "containers": [{"image": "nginx"}]}`,
+			containerPath:      "containers[0]",
+			expectedUser:       "0:nginx",
+			expectedRunsAsRoot: true,
+		},
+		"ContainerRoot": {
+			podSpec: `{"securityContext": {"runAsUser": 1000, "runAsGroup": 3000},
+				"containers": [{"image": "nginx", "securityContext": {"runAsUser": 0}}]}`,
+			containerPath:      "containers[0]",
+			expectedUser:       "0:3000",
+			expectedRunsAsRoot: true,
+		},
+		"ContainerOverridesPod": {
+			podSpec: `{"securityContext": {"runAsUser": 0, "runAsGroup": 0},
+				"initContainers": [{"image": "nginx", "securityContext": {"runAsUser": 2000, "runAsGroup": null}}]}`,
+			containerPath: "initContainers[0]",
+			expectedUser:  "2000:0",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			opt, err := WithSecurityContexts([]byte(testCase.podSpec), rawjson.MustParsePath(testCase.containerPath))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			inspection, err := Inspect(specs.ImageConfig{User: "nginx:nginx"}, opt)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user := inspection.User(); user.String() != testCase.expectedUser {
+				t.Fatalf("unexpected user: %s", user.String())
+			}
+			if inspection.RunsAsRoot() != testCase.expectedRunsAsRoot {
+				t.Fatalf("expected RunsAsRoot to be %t", testCase.expectedRunsAsRoot)
+			}
+		})
+	}
+
+	_, err := WithSecurityContexts([]byte(`{"securityContext": {"runAsUser": "root"}}`), rawjson.MustParsePath("containers[0]"))
+	if err == nil {
+		t.Fatalf("expected an error for the invalid security context")
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		config        specs.ImageConfig
+		expectedError string
+	}{
+		"UserOutOfRange": {
+			config:        specs.ImageConfig{User: "99999999999"},
+			expectedError: `invalid user "99999999999": strconv.ParseUint: parsing "99999999999": value out of range`,
+		},
+		"InvalidPort": {
+			config:        specs.ImageConfig{ExposedPorts: map[string]struct{}{"http/tcp": {}}},
+			expectedError: `invalid exposed port "http/tcp"`,
+		},
+		"PortOutOfRange": {
+			config:        specs.ImageConfig{ExposedPorts: map[string]struct{}{"65536/tcp": {}}},
+			expectedError: `invalid exposed port "65536/tcp"`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := Inspect(testCase.config)
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if _, err := (&OciImageManifestAndConfigResponse{}).Inspect(); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestInspectConfig(t *testing.T) {
+	response := OciImageManifestAndConfigResponse{
+		ImageConfig: &specs.Image{
+			Config: specs.ImageConfig{
+				User:         "1000",
+				ExposedPorts: map[string]struct{}{"8443/tcp": {}, "53/UDP": {}, "8080": {}, "53/tcp": {}},
+				Env:          []string{"PATH=/usr/bin:/bin", "DEBUG", "MODE=dev", "MODE=prod"},
+				Entrypoint:   []string{"/app"},
+				Cmd:          []string{"--port", "8080"},
+				WorkingDir:   "/srv",
+				Labels:       map[string]string{"org.opencontainers.image.source": "https://github.com/kubewarden/app"},
+			},
+		},
+	}
+
+	inspection, err := response.Inspect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ports := []string{}
+	for _, port := range inspection.ExposedPorts() {
+		ports = append(ports, port.String())
+	}
+	if diff := cmp.Diff([]string{"53/tcp", "53/udp", "8080/tcp", "8443/tcp"}, ports); diff != "" {
+		t.Fatalf("unexpected ports:\n%s", diff)
+	}
+	if !inspection.ExposesPort(53, "UDP") || inspection.ExposesPort(8080, "udp") {
+		t.Fatalf("unexpected exposed ports")
+	}
+
+	expectedEnv := map[string]string{"PATH": "/usr/bin:/bin", "DEBUG": "", "MODE": "prod"}
+	if diff := cmp.Diff(expectedEnv, inspection.Env()); diff != "" {
+		t.Fatalf("unexpected env:\n%s", diff)
+	}
+	if value, found := inspection.LookupEnv("DEBUG"); !found || value != "" {
+		t.Fatalf("DEBUG should be defined")
+	}
+
+	if diff := cmp.Diff([]string{"/app"}, inspection.Entrypoint()); diff != "" {
+		t.Fatalf("unexpected entrypoint:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"--port", "8080"}, inspection.Cmd()); diff != "" {
+		t.Fatalf("unexpected cmd:\n%s", diff)
+	}
+	if inspection.WorkingDir() != "/srv" {
+		t.Fatalf("unexpected working dir: %s", inspection.WorkingDir())
+	}
+
+	if !inspection.HasLabel("org.opencontainers.image.source") || inspection.HasLabel("maintainer") {
+		t.Fatalf("unexpected labels: %v", inspection.Labels())
+	}
+	if value, _ := inspection.Label("org.opencontainers.image.source"); value != "https://github.com/kubewarden/app" {
+		t.Fatalf("unexpected label value: %s", value)
+	}
+	if inspection.RunsAsRoot() {
+		t.Fatalf("the image should not run as root")
+	}
+}
@@ -0,0 +1,65 @@
+package manifest_config
+
+import (
+	"fmt"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/oci/manifest"
+	"github.com/kubewarden/policy-sdk-go/pkg/imageref"
+)
+
+// GetOCIManifestAndConfigForPlatform fetches the OCI manifest and the
+// configuration of the image built for the given platform, e.g. `linux`
+// and `arm64`. When the image is an index, the manifest matching the
+// platform is selected using `manifest.SelectPlatform` and then fetched by
+// digest: the manifest of the image must be fetched first, because
+// GetOCIManifestAndConfig resolves the indexes to the platform of the host.
+// When the image has a single manifest, its configuration must declare the
+// platform.
+//
+// It returns an error wrapping `manifest.ErrPlatformNotFound` when the
+// image doesn't support the platform.
+// Arguments:
+// * image: image to be inspected (e.g.: `registry.testing.lan/busybox:1.0.0`).
+// * os, arch, variant: the platform, the variant is optional.
+func GetOCIManifestAndConfigForPlatform(
+	h *capabilities.Host,
+	image, os, arch, variant string,
+) (*OciImageManifestAndConfigResponse, error) {
+	response, err := manifest.GetOCIManifest(h, image)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.IndexManifest() == nil {
+		// single platform image, its configuration tells the platform
+		config, configErr := GetOCIManifestAndConfig(h, image)
+		if configErr != nil {
+			return nil, configErr
+		}
+		if config.ImageConfig == nil {
+			return nil, fmt.Errorf("image %s doesn't have a configuration", image)
+		}
+		platform := config.ImageConfig.Platform
+		if !manifest.MatchesPlatform(&platform, os, arch, variant) {
+			return nil, fmt.Errorf("%w %s: image %s is built only for %s", manifest.ErrPlatformNotFound,
+				manifest.FormatPlatform(os, arch, variant), image,
+				manifest.FormatPlatform(platform.OS, platform.Architecture, platform.Variant))
+		}
+		return config, nil
+	}
+
+	descriptor, err := manifest.SelectPlatform(response.IndexManifest(), os, arch, variant)
+	if err != nil {
+		return nil, fmt.Errorf("image %s: %w", image, err)
+	}
+	ref, err := imageref.Parse(image)
+	if err != nil {
+		return nil, err
+	}
+	ref, err = ref.WithDigest(descriptor.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+	return GetOCIManifestAndConfig(h, ref.String())
+}
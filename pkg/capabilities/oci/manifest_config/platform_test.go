@@ -0,0 +1,96 @@
+package manifest_config
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/mocks"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/oci/manifest"
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func expectHostCall(t *testing.T, client *mocks.MockWapcClient, operation, image string, response interface{}) {
+	t.Helper()
+
+	payload, err := json.Marshal(image)
+	if err != nil {
+		t.Fatalf("cannot serialize image: %v", err)
+	}
+	responsePayload, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("cannot serialize response: %v", err)
+	}
+	client.EXPECT().HostCall("kubewarden", "oci", operation, payload).Return(responsePayload, nil).Times(1)
+}
+
+func TestGetOCIManifestAndConfigForPlatformWithIndex(t *testing.T) {
+	arm64Digest := digest.FromString("arm64")
+	index := specs.Index{
+		MediaType: specs.MediaTypeImageIndex,
+		Manifests: []specs.Descriptor{
+			{Digest: digest.FromString("amd64"), Platform: &specs.Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: arm64Digest, Platform: &specs.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	}
+	config := OciImageManifestAndConfigResponse{
+		Manifest:    &specs.Manifest{MediaType: specs.MediaTypeImageManifest},
+		Digest:      arm64Digest.String(),
+		ImageConfig: &specs.Image{Platform: specs.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+	}
+
+	client := &mocks.MockWapcClient{}
+	expectHostCall(t, client, "v1/oci_manifest", "busybox:1.36", index)
+	expectHostCall(t, client, "v1/oci_manifest_config", "docker.io/library/busybox@"+arm64Digest.String(), config)
+
+	response, err := GetOCIManifestAndConfigForPlatform(&capabilities.Host{Client: client}, "busybox:1.36", "linux", "arm64", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Digest != arm64Digest.String() {
+		t.Fatalf("unexpected digest: %s", response.Digest)
+	}
+	client.AssertExpectations(t)
+
+	client = &mocks.MockWapcClient{}
+	expectHostCall(t, client, "v1/oci_manifest", "busybox:1.36", index)
+	_, err = GetOCIManifestAndConfigForPlatform(&capabilities.Host{Client: client}, "busybox:1.36", "linux", "s390x", "")
+	if !errors.Is(err, manifest.ErrPlatformNotFound) || err.Error() != "image busybox:1.36: no image for the platform linux/s390x" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGetOCIManifestAndConfigForPlatformWithImage(t *testing.T) {
+	image := specs.Manifest{MediaType: specs.MediaTypeImageManifest}
+	config := OciImageManifestAndConfigResponse{
+		Manifest:    &image,
+		Digest:      digest.FromString("amd64").String(),
+		ImageConfig: &specs.Image{Platform: specs.Platform{OS: "linux", Architecture: "amd64"}},
+	}
+
+	client := &mocks.MockWapcClient{}
+	expectHostCall(t, client, "v1/oci_manifest", "ghcr.io/kubewarden/app:v1", image)
+	expectHostCall(t, client, "v1/oci_manifest_config", "ghcr.io/kubewarden/app:v1", config)
+	host := &capabilities.Host{Client: client}
+
+	response, err := GetOCIManifestAndConfigForPlatform(host, "ghcr.io/kubewarden/app:v1", "linux", "x86_64", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Digest != config.Digest {
+		t.Fatalf("unexpected digest: %s", response.Digest)
+	}
+
+	client = &mocks.MockWapcClient{}
+	expectHostCall(t, client, "v1/oci_manifest", "ghcr.io/kubewarden/app:v1", image)
+	expectHostCall(t, client, "v1/oci_manifest_config", "ghcr.io/kubewarden/app:v1", config)
+	host = &capabilities.Host{Client: client}
+
+	_, err = GetOCIManifestAndConfigForPlatform(host, "ghcr.io/kubewarden/app:v1", "linux", "arm64", "")
+	expectedError := "no image for the platform linux/arm64: image ghcr.io/kubewarden/app:v1 is built only for linux/amd64"
+	if !errors.Is(err, manifest.ErrPlatformNotFound) || err.Error() != expectedError {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
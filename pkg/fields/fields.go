@@ -0,0 +1,68 @@
+// This package evaluates Kubernetes field selectors inside of the policies.
+// It's a port of the `fields` package of Kubernetes apimachinery, with the
+// same syntax, semantics and error messages, which doesn't rely on any
+// feature missing from TinyGo.
+//
+//	selector, err := fields.ParseSelector("metadata.namespace!=kube-system,spec.nodeName=")
+//	selector.Matches(fields.Set{"metadata.namespace": "default", "spec.nodeName": ""})
+package fields
+
+import (
+	"sort"
+	"strings"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// Fields allows to inspect a set of fields, regardless of how they are
+// stored.
+type Fields interface {
+	// Has returns whether the field exists.
+	Has(field string) bool
+	// Get returns the value of the field, or the empty string.
+	Get(field string) string
+}
+
+// Set is a map of fields, indexed by their path. It implements Fields.
+type Set map[string]string
+
+// String returns the fields in the `field=value` form, sorted by field and
+// separated by commas.
+func (ls Set) String() string {
+	selector := make([]string, 0, len(ls))
+	for key, value := range ls {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ",")
+}
+
+// Has returns whether the field exists.
+func (ls Set) Has(field string) bool {
+	_, exists := ls[field]
+	return exists
+}
+
+// Get returns the value of the field, or the empty string.
+func (ls Set) Get(field string) string {
+	return ls[field]
+}
+
+// AsSelector returns a selector matching the objects having all the fields
+// of the set.
+func (ls Set) AsSelector() Selector {
+	return SelectorFromSet(ls)
+}
+
+// ObjectMetaFieldsSet returns the fields of the metadata supported by all
+// the Kubernetes resources: `metadata.name`, and `metadata.namespace` for
+// the namespaced resources.
+func ObjectMetaFieldsSet(objectMeta metav1.ObjectMeta, namespaced bool) Set {
+	if !namespaced {
+		return Set{"metadata.name": objectMeta.Name}
+	}
+	return Set{
+		"metadata.name":      objectMeta.Name,
+		"metadata.namespace": objectMeta.Namespace,
+	}
+}
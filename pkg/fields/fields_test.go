@@ -0,0 +1,91 @@
+package fields
+
+import (
+	"testing"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSelector(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		selector         string
+		expectedString   string
+		expectedError    string
+		matchingFields   []Set
+		unmatchingFields []Set
+	}{
+		"Empty": {
+			selector:       "",
+			expectedString: "",
+			matchingFields: []Set{{}, {"metadata.name": "web"}},
+		},
+		"Terms": {
+			selector:         "metadata.namespace!=kube-system,metadata.name==web,spec.nodeName=",
+			expectedString:   "metadata.name=web,metadata.namespace!=kube-system,spec.nodeName=",
+			matchingFields:   []Set{{"metadata.name": "web", "metadata.namespace": "default"}},
+			unmatchingFields: []Set{{"metadata.name": "web", "metadata.namespace": "kube-system"}, {"metadata.name": "api"}},
+		},
+		"EscapedValue": {
+			selector:       `metadata.annotations=a\,b\=c\\d`,
+			expectedString: `metadata.annotations=a\,b\=c\\d`,
+			matchingFields: []Set{{"metadata.annotations": `a,b=c\d`}},
+		},
+		"MissingOperator": {
+			selector:      "metadata.name",
+			expectedError: "invalid selector: 'metadata.name'; can't understand 'metadata.name'",
+		},
+		"InvalidEscape": {
+			selector:      `metadata.name=a\b`,
+			expectedError: `invalid field selector: invalid escape sequence: \b`,
+		},
+		"UnescapedRune": {
+			selector:      "metadata.name=a=b",
+			expectedError: "invalid field selector: unescaped character in value: 61",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			selector, err := ParseSelector(testCase.selector)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if selector.String() != testCase.expectedString {
+				t.Fatalf("unexpected string: %s", selector.String())
+			}
+			for _, fields := range testCase.matchingFields {
+				if !selector.Matches(fields) {
+					t.Fatalf("%q should match %v", testCase.selector, fields)
+				}
+			}
+			for _, fields := range testCase.unmatchingFields {
+				if selector.Matches(fields) {
+					t.Fatalf("%q should not match %v", testCase.selector, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestObjectMetaFieldsSet(t *testing.T) {
+	objectMeta := metav1.ObjectMeta{Name: "web", Namespace: "default"}
+
+	selector := ParseSelectorOrDie("metadata.name=web,metadata.namespace=default")
+	if !selector.Matches(ObjectMetaFieldsSet(objectMeta, true)) {
+		t.Fatalf("the selector should match the namespaced object")
+	}
+	if selector.Matches(ObjectMetaFieldsSet(objectMeta, false)) {
+		t.Fatalf("the selector should not match the cluster-wide object")
+	}
+	if value, found := selector.RequiresExactMatch("metadata.namespace"); !found || value != "default" {
+		t.Fatalf("unexpected exact match: %s, %t", value, found)
+	}
+	if Everything().String() != "" || !Everything().Empty() {
+		t.Fatalf("unexpected everything selector")
+	}
+}
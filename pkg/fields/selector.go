@@ -0,0 +1,334 @@
+package fields
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	notEqualOperator    = "!="
+	doubleEqualOperator = "=="
+	equalOperator       = "="
+)
+
+// Selector represents a field selector.
+type Selector interface {
+	// Matches returns true if this selector matches the given set of
+	// fields.
+	Matches(fields Fields) bool
+	// Empty returns true if this selector doesn't restrict the selection
+	// space.
+	Empty() bool
+	// RequiresExactMatch returns the value the field must have in order to
+	// match the selector, if any.
+	RequiresExactMatch(field string) (string, bool)
+	// Requirements returns the requirements of this selector.
+	Requirements() []Requirement
+	// String returns a human readable string that represents this
+	// selector, which can be parsed back by ParseSelector.
+	String() string
+}
+
+// Operator is the operator of a Requirement.
+type Operator string
+
+const (
+	Equals    Operator = "="
+	NotEquals Operator = "!="
+)
+
+// Requirement is a condition on the value of a field.
+type Requirement struct {
+	Operator Operator
+	Field    string
+	Value    string
+}
+
+// Everything returns a selector that matches all the fields.
+func Everything() Selector {
+	return andTerm{}
+}
+
+type hasTerm struct {
+	field, value string
+}
+
+func (t *hasTerm) Matches(ls Fields) bool {
+	return ls.Get(t.field) == t.value
+}
+
+func (t *hasTerm) Empty() bool {
+	return false
+}
+
+func (t *hasTerm) RequiresExactMatch(field string) (string, bool) {
+	if t.field == field {
+		return t.value, true
+	}
+	return "", false
+}
+
+func (t *hasTerm) Requirements() []Requirement {
+	return []Requirement{{Field: t.field, Operator: Equals, Value: t.value}}
+}
+
+func (t *hasTerm) String() string {
+	return fmt.Sprintf("%v=%v", t.field, EscapeValue(t.value))
+}
+
+type notHasTerm struct {
+	field, value string
+}
+
+func (t *notHasTerm) Matches(ls Fields) bool {
+	return ls.Get(t.field) != t.value
+}
+
+func (t *notHasTerm) Empty() bool {
+	return false
+}
+
+func (t *notHasTerm) RequiresExactMatch(_ string) (string, bool) {
+	return "", false
+}
+
+func (t *notHasTerm) Requirements() []Requirement {
+	return []Requirement{{Field: t.field, Operator: NotEquals, Value: t.value}}
+}
+
+func (t *notHasTerm) String() string {
+	return fmt.Sprintf("%v!=%v", t.field, EscapeValue(t.value))
+}
+
+// andTerm is a list of selectors, all of them must match.
+type andTerm []Selector
+
+func (t andTerm) Matches(ls Fields) bool {
+	for _, q := range t {
+		if !q.Matches(ls) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t andTerm) Empty() bool {
+	for _, q := range t {
+		if !q.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (t andTerm) RequiresExactMatch(field string) (string, bool) {
+	if len(t) == 0 {
+		return "", false
+	}
+	for _, q := range t {
+		if value, found := q.RequiresExactMatch(field); found {
+			return value, found
+		}
+	}
+	return "", false
+}
+
+func (t andTerm) Requirements() []Requirement {
+	requirements := []Requirement{}
+	for _, q := range t {
+		requirements = append(requirements, q.Requirements()...)
+	}
+	return requirements
+}
+
+func (t andTerm) String() string {
+	terms := make([]string, 0, len(t))
+	for _, q := range t {
+		terms = append(terms, q.String())
+	}
+	return strings.Join(terms, ",")
+}
+
+// SelectorFromSet returns a selector matching the objects having all the
+// fields of the set.
+func SelectorFromSet(ls Set) Selector {
+	if ls == nil {
+		return Everything()
+	}
+	items := make([]Selector, 0, len(ls))
+	for field, value := range ls {
+		items = append(items, &hasTerm{field: field, value: value})
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].String() < items[j].String()
+	})
+	return andTerm(items)
+}
+
+// OneTermEqualSelector returns a selector matching the objects whose field
+// has the given value.
+func OneTermEqualSelector(field, value string) Selector {
+	return &hasTerm{field: field, value: value}
+}
+
+// OneTermNotEqualSelector returns a selector matching the objects whose
+// field doesn't have the given value.
+func OneTermNotEqualSelector(field, value string) Selector {
+	return &notHasTerm{field: field, value: value}
+}
+
+// AndSelectors returns a selector matching the objects matched by all the
+// selectors.
+func AndSelectors(selectors ...Selector) Selector {
+	return andTerm(selectors)
+}
+
+// EscapeValue escapes the backslashes, the commas and the equal signs of a
+// value, which have a special meaning inside of the selectors.
+func EscapeValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`, `=`, `\=`).Replace(s)
+}
+
+// InvalidEscapeSequenceError is returned when a value contains an escape
+// sequence other than `\\`, `\,` and `\=`.
+type InvalidEscapeSequenceError struct {
+	sequence string
+}
+
+func (i InvalidEscapeSequenceError) Error() string {
+	return "invalid field selector: invalid escape sequence: " + i.sequence
+}
+
+// UnescapedRuneError is returned when a value contains a comma or an equal
+// sign that is not escaped.
+type UnescapedRuneError struct {
+	r rune
+}
+
+func (i UnescapedRuneError) Error() string {
+	return fmt.Sprintf("invalid field selector: unescaped character in value: %v", i.r)
+}
+
+// UnescapeValue unescapes a value escaped by EscapeValue.
+func UnescapeValue(s string) (string, error) {
+	if !strings.ContainsAny(s, `\,=`) {
+		return s, nil
+	}
+
+	v := bytes.NewBuffer(make([]byte, 0, len(s)))
+	inSlash := false
+	for _, c := range s {
+		if inSlash {
+			switch c {
+			case '\\', ',', '=':
+				v.WriteRune(c)
+			default:
+				return "", InvalidEscapeSequenceError{sequence: string([]rune{'\\', c})}
+			}
+			inSlash = false
+			continue
+		}
+
+		switch c {
+		case '\\':
+			inSlash = true
+		case ',', '=':
+			return "", UnescapedRuneError{r: c}
+		default:
+			v.WriteRune(c)
+		}
+	}
+
+	if inSlash {
+		return "", InvalidEscapeSequenceError{sequence: "\\"}
+	}
+	return v.String(), nil
+}
+
+// ParseSelector parses the string representation of a selector: a list of
+// `field=value`, `field==value` or `field!=value` terms separated by
+// commas, all of them must be satisfied. Commas, equal signs and
+// backslashes inside of the values must be escaped with a backslash.
+func ParseSelector(selector string) (Selector, error) {
+	parts := splitTerms(selector)
+	sort.Strings(parts)
+
+	var items []Selector
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		lhs, op, rhs, ok := splitTerm(part)
+		if !ok {
+			return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
+		}
+		unescapedRHS, err := UnescapeValue(rhs)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case notEqualOperator:
+			items = append(items, &notHasTerm{field: lhs, value: unescapedRHS})
+		default:
+			items = append(items, &hasTerm{field: lhs, value: unescapedRHS})
+		}
+	}
+
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return andTerm(items), nil
+}
+
+// ParseSelectorOrDie is like ParseSelector, but panics when the selector
+// is not valid. It's meant to be used with constant selectors.
+func ParseSelectorOrDie(selector string) Selector {
+	parsed, err := ParseSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// splitTerms returns the comma-separated terms of the selector, ignoring
+// the escaped commas.
+func splitTerms(fieldSelector string) []string {
+	if fieldSelector == "" {
+		return nil
+	}
+
+	terms := make([]string, 0, 1)
+	startIndex := 0
+	inSlash := false
+	for i, c := range fieldSelector {
+		switch {
+		case inSlash:
+			inSlash = false
+		case c == '\\':
+			inSlash = true
+		case c == ',':
+			terms = append(terms, fieldSelector[startIndex:i])
+			startIndex = i + 1
+		}
+	}
+	return append(terms, fieldSelector[startIndex:])
+}
+
+// splitTerm returns the left-hand side, the operator and the right-hand
+// side of a term, splitting at the first operator found.
+func splitTerm(term string) (string, string, string, bool) {
+	for i := range term {
+		remaining := term[i:]
+		for _, op := range []string{notEqualOperator, doubleEqualOperator, equalOperator} {
+			if strings.HasPrefix(remaining, op) {
+				return term[0:i], op, term[i+len(op):], true
+			}
+		}
+	}
+	return "", "", "", false
+}
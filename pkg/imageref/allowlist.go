@@ -0,0 +1,165 @@
+package imageref
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/internal/duplicates"
+)
+
+// prefixWildcard ends the repository patterns matching all the
+// repositories below a path.
+const prefixWildcard = "/**"
+
+// Allowlist matches the images by registry or by repository, usually
+// provided by the settings of the policies:
+//
+//	type Settings struct {
+//		Allowed imageref.Allowlist `json:"allowed"`
+//	}
+//
+// The patterns use the syntax of `path.Match`, where `*` matches any
+// sequence of characters except `/`.
+//
+// The zero value doesn't match any image.
+type Allowlist struct {
+	// Registries holds the registry patterns, like `ghcr.io`,
+	// `localhost:5000` or `*.gcr.io`
+	Registries []string `json:"registries,omitempty"`
+	// Repositories holds the repository patterns, made by the registry and
+	// the repository, like `ghcr.io/kubewarden/policy-server` or
+	// `ghcr.io/kubewarden/*`. Patterns ending with `/**` match all the
+	// repositories below the path, like `ghcr.io/kubewarden/**`. Patterns
+	// are normalized like the references, `nginx` being
+	// `docker.io/library/nginx`
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+// IsEmpty tells whether the allowlist has neither registries nor
+// repositories.
+func (a Allowlist) IsEmpty() bool {
+	return len(a.Registries) == 0 && len(a.Repositories) == 0
+}
+
+// Matches tells whether the image comes from one of the registries, or from
+// one of the repositories, of the allowlist.
+func (a Allowlist) Matches(ref Reference) bool {
+	for _, pattern := range a.Registries {
+		if matched, _ := path.Match(normalizeRegistryPattern(pattern), ref.Registry); matched {
+			return true
+		}
+	}
+	for _, pattern := range a.Repositories {
+		if matchesRepository(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesImage parses the image reference and tells whether it matches the
+// allowlist.
+func (a Allowlist) MatchesImage(image string) (bool, error) {
+	ref, err := Parse(image)
+	if err != nil {
+		return false, err
+	}
+	return a.Matches(ref), nil
+}
+
+// Validate checks the patterns of the allowlist. All the problems are
+// reported, joined with `errors.Join`, so that the allowlist can be
+// validated as part of the settings of a policy.
+func (a Allowlist) Validate() error {
+	errs := []error{}
+	for _, pattern := range a.Registries {
+		if err := validateRegistryPattern(pattern); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, pattern := range a.Repositories {
+		if err := validateRepositoryPattern(pattern); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if duplicate, found := duplicates.Find(a.Registries); found {
+		errs = append(errs, fmt.Errorf("registry %q is listed more than once", duplicate))
+	}
+	if duplicate, found := duplicates.Find(a.Repositories); found {
+		errs = append(errs, fmt.Errorf("repository %q is listed more than once", duplicate))
+	}
+	return errors.Join(errs...)
+}
+
+func normalizeRegistryPattern(pattern string) string {
+	if pattern == legacyDefaultRegistry {
+		return DefaultRegistry
+	}
+	return pattern
+}
+
+// normalizeRepositoryPattern normalizes the pattern like the names of the
+// images, and tells whether it's a prefix pattern, whose `/**` suffix is
+// removed.
+func normalizeRepositoryPattern(pattern string) (string, bool) {
+	registry, repository := splitRegistry(pattern)
+	if registry == DefaultRegistry && !strings.Contains(repository, "/") && repository != "**" {
+		repository = officialRepositoryPrefix + repository
+	}
+	return strings.CutSuffix(registry+"/"+repository, prefixWildcard)
+}
+
+// matchesRepository tells whether the registry and the repository of the
+// reference match the pattern.
+func matchesRepository(pattern string, ref Reference) bool {
+	normalized, isPrefix := normalizeRepositoryPattern(pattern)
+	if !isPrefix {
+		matched, _ := path.Match(normalized, ref.Name())
+		return matched
+	}
+
+	// a prefix made by n components matches the names with more than n
+	// components, whose first n components match it
+	components := strings.Count(normalized, "/") + 1
+	nameComponents := strings.Split(ref.Name(), "/")
+	if len(nameComponents) <= components {
+		return false
+	}
+	matched, _ := path.Match(normalized, strings.Join(nameComponents[:components], "/"))
+	return matched
+}
+
+func validateRegistryPattern(pattern string) error {
+	switch {
+	case strings.TrimSpace(pattern) == "":
+		return errors.New("registry patterns cannot be empty")
+	case strings.Contains(pattern, "/"):
+		return fmt.Errorf("invalid registry %q: registries cannot contain `/`", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid registry %q: %w", pattern, err)
+	}
+	return nil
+}
+
+func validateRepositoryPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("repository patterns cannot be empty")
+	}
+	prefix := strings.TrimSuffix(pattern, prefixWildcard)
+	_, repository := splitRegistry(pattern)
+	switch {
+	case prefix == "":
+		return fmt.Errorf("invalid repository %q: `/**` must follow a path", pattern)
+	case strings.Contains(prefix, "**"):
+		return fmt.Errorf("invalid repository %q: `**` is allowed only as the last path component", pattern)
+	case strings.ContainsAny(repository, "@:"):
+		return fmt.Errorf("invalid repository %q: repositories cannot contain tags or digests", pattern)
+	}
+	if _, err := path.Match(prefix, ""); err != nil {
+		return fmt.Errorf("invalid repository %q: %w", pattern, err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package imageref
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDigest = "sha256:4c0d3a2b7a5e1e3e5d8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d"
+
+func TestParse(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		reference      string
+		expected       Reference
+		expectedString string
+		expectedPinned bool
+	}{
+		"OfficialImage": {
+			reference:      "nginx",
+			expected:       Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+			expectedString: "docker.io/library/nginx:latest",
+		},
+		"DockerHubNamespace": {
+			reference:      "bitnami/redis:7.2",
+			expected:       Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"},
+			expectedString: "docker.io/bitnami/redis:7.2",
+		},
+		"LegacyDockerHub": {
+			reference:      "index.docker.io/busybox:1.36",
+			expected:       Reference{Registry: "docker.io", Repository: "library/busybox", Tag: "1.36"},
+			expectedString: "docker.io/library/busybox:1.36",
+		},
+		"Registry": {
+			reference:      "ghcr.io/kubewarden/policy-server:v1.10.0",
+			expected:       Reference{Registry: "ghcr.io", Repository: "kubewarden/policy-server", Tag: "v1.10.0"},
+			expectedString: "ghcr.io/kubewarden/policy-server:v1.10.0",
+		},
+		"RegistryWithPort": {
+			reference:      "localhost:5000/team/app",
+			expected:       Reference{Registry: "localhost:5000", Repository: "team/app", Tag: "latest"},
+			expectedString: "localhost:5000/team/app:latest",
+		},
+		"Localhost": {
+			reference:      "localhost/app",
+			expected:       Reference{Registry: "localhost", Repository: "app", Tag: "latest"},
+			expectedString: "localhost/app:latest",
+		},
+		"Digest": {
+			reference:      "quay.io/prometheus/node-exporter@" + testDigest,
+			expected:       Reference{Registry: "quay.io", Repository: "prometheus/node-exporter", Digest: testDigest},
+			expectedString: "quay.io/prometheus/node-exporter@" + testDigest,
+			expectedPinned: true,
+		},
+		"TagAndDigest": {
+			reference:      "nginx:1.25@" + testDigest,
+			expected:       Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25", Digest: testDigest},
+			expectedString: "docker.io/library/nginx:1.25@" + testDigest,
+			expectedPinned: true,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			ref, err := Parse(testCase.reference)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expected, ref); diff != "" {
+				t.Fatalf("unexpected reference:\n%s", diff)
+			}
+			if ref.String() != testCase.expectedString {
+				t.Fatalf("unexpected string: %s", ref.String())
+			}
+			if ref.IsPinned() != testCase.expectedPinned {
+				t.Fatalf("expected pinned to be %t", testCase.expectedPinned)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		reference     string
+		expectedError string
+	}{
+		"Empty": {
+			reference:     "",
+			expectedError: "invalid image reference: empty reference",
+		},
+		"Uppercase": {
+			reference:     "ghcr.io/Kubewarden/policy",
+			expectedError: `invalid image reference "ghcr.io/Kubewarden/policy": repository name must be lowercase`,
+		},
+		"InvalidTag": {
+			reference:     "nginx:-latest",
+			expectedError: `invalid image reference "nginx:-latest": invalid tag "-latest"`,
+		},
+		"EmptyTag": {
+			reference:     "nginx:",
+			expectedError: `invalid image reference "nginx:": invalid tag ""`,
+		},
+		"ShortDigest": {
+			reference:     "nginx@sha256:1234",
+			expectedError: `invalid image reference "nginx@sha256:1234": invalid digest "sha256:1234"`,
+		},
+		"InvalidSha256": {
+			reference: "nginx@sha256:" + strings.Repeat("z", 64),
+			expectedError: `invalid image reference "nginx@sha256:` + strings.Repeat("z", 64) + `": ` +
+				`invalid sha256 digest "sha256:` + strings.Repeat("z", 64) + `"`,
+		},
+		"InvalidRepository": {
+			reference:     "ghcr.io/kubewarden//policy",
+			expectedError: `invalid image reference "ghcr.io/kubewarden//policy": invalid repository "kubewarden//policy"`,
+		},
+		"InvalidRegistry": {
+			reference:     "-ghcr.io/kubewarden/policy",
+			expectedError: `invalid image reference "-ghcr.io/kubewarden/policy": invalid registry "-ghcr.io"`,
+		},
+		"TooLong": {
+			reference: "ghcr.io/" + strings.Repeat("a", 250),
+			expectedError: `invalid image reference "ghcr.io/` + strings.Repeat("a", 250) + `": ` +
+				`repository name must not be more than 255 characters`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := Parse(testCase.reference)
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, ErrInvalidReference) {
+				t.Fatalf("the error should wrap ErrInvalidReference")
+			}
+		})
+	}
+}
+
+func TestWithDigest(t *testing.T) {
+	pinned, err := MustParse("nginx:1.25").WithDigest(testDigest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "docker.io/library/nginx@" + testDigest; pinned.String() != expected {
+		t.Fatalf("unexpected reference: %s", pinned.String())
+	}
+
+	_, err = MustParse("nginx:1.25").WithDigest("latest")
+	expectedError := `invalid image reference "docker.io/library/nginx:1.25": invalid digest "latest"`
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAllowlistMatches(t *testing.T) {
+	allowlist := Allowlist{
+		Registries:   []string{"*.gcr.io", "index.docker.io"},
+		Repositories: []string{"ghcr.io/kubewarden/*", "quay.io/prometheus/**", "registry.k8s.io/pause"},
+	}
+
+	for description, testCase := range map[string]struct {
+		image    string
+		expected bool
+	}{
+		"RegistryGlob":           {image: "eu.gcr.io/project/app", expected: true},
+		"RegistryGlobNoSub":      {image: "gcr.io/project/app"},
+		"NormalizedRegistry":     {image: "nginx", expected: true},
+		"RepositoryGlob":         {image: "ghcr.io/kubewarden/policy-server:v1.10.0", expected: true},
+		"RepositoryGlobNested":   {image: "ghcr.io/kubewarden/policies/pod-privileged"},
+		"RepositoryPrefix":       {image: "quay.io/prometheus/node-exporter", expected: true},
+		"RepositoryPrefixDeep":   {image: "quay.io/prometheus/exporters/blackbox", expected: true},
+		"RepositoryPrefixItself": {image: "quay.io/prometheus"},
+		"ExactRepository":        {image: "registry.k8s.io/pause:3.9", expected: true},
+		"OtherRepository":        {image: "registry.k8s.io/pause-other"},
+		"OtherRegistry":          {image: "ghcr.io/other/app"},
+	} {
+		t.Run(description, func(t *testing.T) {
+			matches, err := allowlist.MatchesImage(testCase.image)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matches != testCase.expected {
+				t.Fatalf("image %s: expected %t, got %t", testCase.image, testCase.expected, matches)
+			}
+		})
+	}
+
+	if (Allowlist{}).Matches(MustParse("nginx")) {
+		t.Fatalf("the empty allowlist should not match any image")
+	}
+	dockerHub := Allowlist{Repositories: []string{"nginx", "bitnami/**"}}
+	if !dockerHub.Matches(MustParse("docker.io/library/nginx:1.25")) || !dockerHub.Matches(MustParse("bitnami/redis")) {
+		t.Fatalf("the Docker Hub repositories should be normalized")
+	}
+	if dockerHub.Matches(MustParse("library/bitnami")) {
+		t.Fatalf("prefix patterns should not be official images")
+	}
+	wholeRegistry := Allowlist{Repositories: []string{"docker.io/**"}}
+	if !wholeRegistry.Matches(MustParse("bitnami/redis")) || !wholeRegistry.Matches(MustParse("nginx")) {
+		t.Fatalf("the prefix pattern should match the whole registry")
+	}
+}
+
+func TestAllowlistValidate(t *testing.T) {
+	var allowlist Allowlist
+	err := json.Unmarshal([]byte(`{
+		"registries": ["ghcr.io", "", "ghcr.io/kubewarden", "[a-"],
+		"repositories": ["nginx:latest", "/**", "ghcr.io/**/policy", "ghcr.io/kubewarden/**", "ghcr.io/kubewarden/**"]
+	}`), &allowlist)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "registry patterns cannot be empty\n" +
+		"invalid registry \"ghcr.io/kubewarden\": registries cannot contain `/`\n" +
+		"invalid registry \"[a-\": syntax error in pattern\n" +
+		"invalid repository \"nginx:latest\": repositories cannot contain tags or digests\n" +
+		"invalid repository \"/**\": `/**` must follow a path\n" +
+		"invalid repository \"ghcr.io/**/policy\": `**` is allowed only as the last path component\n" +
+		"repository \"ghcr.io/kubewarden/**\" is listed more than once"
+	if err = allowlist.Validate(); err == nil || err.Error() != expected {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	valid := Allowlist{Registries: []string{"*.gcr.io"}, Repositories: []string{"ghcr.io/kubewarden/**", "localhost:5000/**"}}
+	if err = valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
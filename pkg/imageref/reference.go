@@ -0,0 +1,221 @@
+// This package parses the references of the container images, like the
+// ones found inside of `Container.Image` or given to the OCI capabilities of
+// the host, and matches them against allowlists of registries and
+// repositories:
+//
+//	ref, err := imageref.Parse("nginx")
+//	// ref.Registry == "docker.io"
+//	// ref.Repository == "library/nginx"
+//	// ref.Tag == "latest"
+//	// ref.String() == "docker.io/library/nginx:latest"
+//
+// References are normalized like Docker does: images without a registry
+// come from Docker Hub, and the official images of Docker Hub belong to the
+// `library` namespace.
+package imageref
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry of the images whose reference
+	// doesn't include one.
+	DefaultRegistry = "docker.io"
+	// DefaultTag is the tag of the images whose reference has neither a tag
+	// nor a digest.
+	DefaultTag = "latest"
+
+	// legacyDefaultRegistry is an alias of DefaultRegistry.
+	legacyDefaultRegistry = "index.docker.io"
+	// officialRepositoryPrefix is the namespace of the official images of
+	// Docker Hub.
+	officialRepositoryPrefix = "library/"
+	// localhost is a registry name that doesn't contain any dot.
+	localhost = "localhost"
+	// nameMaxLength is the maximum length of the registry and repository.
+	nameMaxLength = 255
+)
+
+// The grammar of the references, as defined by the distribution project.
+const (
+	alphanumericFmt    = `[a-z0-9]+`
+	separatorFmt       = `(?:[._]|__|[-]+)`
+	pathComponentFmt   = alphanumericFmt + `(?:` + separatorFmt + alphanumericFmt + `)*`
+	domainComponentFmt = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+	domainFmt          = domainComponentFmt + `(?:\.` + domainComponentFmt + `)*(?::[0-9]+)?`
+	tagFmt             = `[\w][\w.-]{0,127}`
+	digestFmt          = `[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}`
+	sha256Fmt          = `sha256:[a-f0-9]{64}`
+)
+
+//nolint:gochecknoglobals // compiled once, the expressions are constant
+var (
+	pathComponentRegexp = regexp.MustCompile("^" + pathComponentFmt + "$")
+	domainRegexp        = regexp.MustCompile("^" + domainFmt + "$")
+	tagRegexp           = regexp.MustCompile("^" + tagFmt + "$")
+	digestRegexp        = regexp.MustCompile("^" + digestFmt + "$")
+	sha256Regexp        = regexp.MustCompile("^" + sha256Fmt + "$")
+)
+
+// ErrInvalidReference is wrapped by all the errors returned when a
+// reference cannot be parsed.
+var ErrInvalidReference = errors.New("invalid image reference")
+
+// Reference is a normalized image reference.
+type Reference struct {
+	// Registry is the host of the registry, optionally followed by a port,
+	// like `docker.io` or `localhost:5000`
+	Registry string
+	// Repository is the path of the repository inside of the registry, like
+	// `library/nginx`
+	Repository string
+	// Tag is the tag of the image, it's empty when the reference has a
+	// digest but no tag
+	Tag string
+	// Digest is the digest of the image, like `sha256:...`, it's empty when
+	// the reference is not pinned
+	Digest string
+}
+
+// Parse parses and normalizes an image reference. References without a tag
+// and a digest get the `latest` tag.
+func Parse(reference string) (Reference, error) {
+	if reference == "" {
+		return Reference{}, fmt.Errorf("%w: empty reference", ErrInvalidReference)
+	}
+
+	name, digest, hasDigest := strings.Cut(reference, "@")
+	if hasDigest {
+		if err := validateDigest(digest); err != nil {
+			return Reference{}, fmt.Errorf("%w %q: %w", ErrInvalidReference, reference, err)
+		}
+	}
+
+	tag := ""
+	if separator := strings.LastIndex(name, ":"); separator > strings.LastIndex(name, "/") {
+		name, tag = name[:separator], name[separator+1:]
+		if !tagRegexp.MatchString(tag) {
+			return Reference{}, fmt.Errorf("%w %q: invalid tag %q", ErrInvalidReference, reference, tag)
+		}
+	}
+
+	registry, repository := splitName(name)
+	if err := validateName(registry, repository); err != nil {
+		return Reference{}, fmt.Errorf("%w %q: %w", ErrInvalidReference, reference, err)
+	}
+
+	if tag == "" && digest == "" {
+		tag = DefaultTag
+	}
+	return Reference{
+		Registry:   registry,
+		Repository: repository,
+		Tag:        tag,
+		Digest:     digest,
+	}, nil
+}
+
+// MustParse is like Parse, but panics when the reference is not valid. It's
+// meant to be used with constant references.
+func MustParse(reference string) Reference {
+	ref, err := Parse(reference)
+	if err != nil {
+		panic(err)
+	}
+	return ref
+}
+
+// splitName splits the name of an image into its registry and its
+// repository, applying the normalization rules of Docker Hub.
+func splitName(name string) (string, string) {
+	registry, repository := splitRegistry(name)
+	if registry == DefaultRegistry && !strings.Contains(repository, "/") {
+		repository = officialRepositoryPrefix + repository
+	}
+	return registry, repository
+}
+
+// splitRegistry splits the registry from the rest of the name. The first
+// component of the name is a registry when it contains a dot or a port, or
+// when it's `localhost`.
+func splitRegistry(name string) (string, string) {
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(registry, ".:") && registry != localhost &&
+		strings.ToLower(registry) == registry) {
+		registry, repository = DefaultRegistry, name
+	}
+	if registry == legacyDefaultRegistry {
+		registry = DefaultRegistry
+	}
+	return registry, repository
+}
+
+func validateName(registry, repository string) error {
+	if !domainRegexp.MatchString(registry) {
+		return fmt.Errorf("invalid registry %q", registry)
+	}
+	if len(registry)+len(repository)+1 > nameMaxLength {
+		return fmt.Errorf("repository name must not be more than %d characters", nameMaxLength)
+	}
+	if strings.ToLower(repository) != repository {
+		return errors.New("repository name must be lowercase")
+	}
+	for _, component := range strings.Split(repository, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return fmt.Errorf("invalid repository %q", repository)
+		}
+	}
+	return nil
+}
+
+func validateDigest(digest string) error {
+	if !digestRegexp.MatchString(digest) {
+		return fmt.Errorf("invalid digest %q", digest)
+	}
+	if strings.HasPrefix(digest, "sha256:") && !sha256Regexp.MatchString(digest) {
+		return fmt.Errorf("invalid sha256 digest %q", digest)
+	}
+	return nil
+}
+
+// Name returns the registry and the repository of the image, like
+// `docker.io/library/nginx`.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the normalized reference, like
+// `docker.io/library/nginx:latest`.
+func (r Reference) String() string {
+	formatted := r.Name()
+	if r.Tag != "" {
+		formatted += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		formatted += "@" + r.Digest
+	}
+	return formatted
+}
+
+// IsPinned tells whether the reference has a digest, which identifies the
+// image regardless of its tag.
+func (r Reference) IsPinned() bool {
+	return r.Digest != ""
+}
+
+// WithDigest returns the digest-pinned form of the reference, like
+// `docker.io/library/nginx@sha256:...`. The tag is removed, because the
+// container runtimes ignore it once the digest is set.
+func (r Reference) WithDigest(digest string) (Reference, error) {
+	if err := validateDigest(digest); err != nil {
+		return Reference{}, fmt.Errorf("%w %q: %w", ErrInvalidReference, r.String(), err)
+	}
+	pinned := r
+	pinned.Tag = ""
+	pinned.Digest = digest
+	return pinned, nil
+}
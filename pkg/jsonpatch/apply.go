@@ -0,0 +1,281 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/internal/jsonvalue"
+)
+
+// Apply applies the patch to the given JSON document and returns the
+// patched document. The original document is not modified.
+//
+// Note: the keys of the JSON objects inside of the returned document are
+// sorted.
+func Apply(document []byte, patch Patch) ([]byte, error) {
+	doc, err := decode(document)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode document: %w", err)
+	}
+
+	for i, operation := range patch {
+		doc, err = applyOperation(doc, operation)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply operation #%d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+func applyOperation(doc interface{}, operation Operation) (interface{}, error) {
+	switch operation.Op {
+	case OperationAdd:
+		return add(doc, operation.Path, operation.Value)
+	case OperationRemove:
+		updated, _, err := remove(doc, operation.Path)
+		return updated, err
+	case OperationReplace:
+		updated, _, err := remove(doc, operation.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(updated, operation.Path, operation.Value)
+	case OperationMove:
+		if strings.HasPrefix(operation.Path, operation.From+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		updated, value, err := remove(doc, operation.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(updated, operation.Path, value)
+	case OperationCopy:
+		value, err := get(doc, operation.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, operation.Path, deepCopy(value))
+	case OperationTest:
+		value, err := get(doc, operation.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonvalue.Equal(value, normalize(operation.Value)) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", operation.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = UnescapeToken(token)
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, found := container[token]
+			if !found {
+				return nil, fmt.Errorf("key %q not found", token)
+			}
+			current = value
+		case []interface{}:
+			index, indexErr := arrayIndex(token, len(container)-1)
+			if indexErr != nil {
+				return nil, indexErr
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("cannot traverse %q: not an object or an array", token)
+		}
+	}
+	return current, nil
+}
+
+// add sets the value at the location referenced by the pointer and
+// returns the updated document.
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	value = normalize(value)
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return update(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch parent := container.(type) {
+		case map[string]interface{}:
+			parent[token] = value
+			return parent, nil
+		case []interface{}:
+			index := len(parent)
+			if token != "-" {
+				index, err = arrayIndex(token, len(parent))
+				if err != nil {
+					return nil, err
+				}
+			}
+			parent = append(parent, nil)
+			copy(parent[index+1:], parent[index:])
+			parent[index] = value
+			return parent, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q: parent is not an object or an array", token)
+		}
+	})
+}
+
+// remove deletes the value at the location referenced by the pointer and
+// returns the updated document together with the removed value.
+func remove(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed interface{}
+	doc, err = update(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch parent := container.(type) {
+		case map[string]interface{}:
+			value, found := parent[token]
+			if !found {
+				return nil, fmt.Errorf("key %q not found", token)
+			}
+			removed = value
+			delete(parent, token)
+			return parent, nil
+		case []interface{}:
+			index, indexErr := arrayIndex(token, len(parent)-1)
+			if indexErr != nil {
+				return nil, indexErr
+			}
+			removed = parent[index]
+			return append(parent[:index:index], parent[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %q: parent is not an object or an array", token)
+		}
+	})
+	return doc, removed, err
+}
+
+// update walks the document down to the parent of the last token and
+// replaces it with the value returned by `change`. Arrays are replaced
+// because changing their length creates a new slice.
+func update(
+	doc interface{},
+	tokens []string,
+	change func(container interface{}, token string) (interface{}, error),
+) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change(doc, tokens[0])
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		child, found := container[tokens[0]]
+		if !found {
+			return nil, fmt.Errorf("key %q not found", tokens[0])
+		}
+		updated, err := update(child, tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[tokens[0]] = updated
+		return container, nil
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(container[index], tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse %q: not an object or an array", tokens[0])
+	}
+}
+
+func arrayIndex(token string, maxIndex int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > maxIndex {
+		return 0, fmt.Errorf("array index %d out of bounds", index)
+	}
+	return index, nil
+}
+
+// normalize converts values provided by the user, like Go structs, into
+// the generic representation used while patching.
+func normalize(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, bool, string, json.Number, map[string]interface{}, []interface{}:
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	normalized, err := decode(data)
+	if err != nil {
+		return value
+	}
+	return normalized
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,112 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/kubewarden/policy-sdk-go/internal/jsonvalue"
+)
+
+// CreatePatch computes the JSON Patch that transforms the `original` JSON
+// document into the `modified` one.
+//
+// Object keys are visited in lexical order, so the same input always
+// produces the same patch. Arrays are compared element by element after
+// skipping their common prefix and suffix, this keeps the patch small when
+// elements are inserted or removed.
+func CreatePatch(original, modified []byte) (Patch, error) {
+	originalDoc, err := decode(original)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode original document: %w", err)
+	}
+	modifiedDoc, err := decode(modified)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode modified document: %w", err)
+	}
+
+	patch := Patch{}
+	diff("", originalDoc, modifiedDoc, &patch)
+	return patch, nil
+}
+
+func diff(path string, original, modified interface{}, patch *Patch) {
+	switch originalValue := original.(type) {
+	case map[string]interface{}:
+		if modifiedValue, ok := modified.(map[string]interface{}); ok {
+			diffObjects(path, originalValue, modifiedValue, patch)
+			return
+		}
+	case []interface{}:
+		if modifiedValue, ok := modified.([]interface{}); ok {
+			diffArrays(path, originalValue, modifiedValue, patch)
+			return
+		}
+	}
+
+	if !jsonvalue.Equal(original, modified) {
+		*patch = append(*patch, Operation{Op: OperationReplace, Path: path, Value: modified})
+	}
+}
+
+func diffObjects(path string, original, modified map[string]interface{}, patch *Patch) {
+	for _, key := range sortedKeys(original) {
+		if _, found := modified[key]; !found {
+			*patch = append(*patch, Operation{Op: OperationRemove, Path: path + "/" + EscapeToken(key)})
+		}
+	}
+
+	for _, key := range sortedKeys(modified) {
+		childPath := path + "/" + EscapeToken(key)
+		originalValue, found := original[key]
+		if !found {
+			*patch = append(*patch, Operation{Op: OperationAdd, Path: childPath, Value: modified[key]})
+			continue
+		}
+		diff(childPath, originalValue, modified[key], patch)
+	}
+}
+
+func diffArrays(path string, original, modified []interface{}, patch *Patch) {
+	prefix := 0
+	for prefix < len(original) && prefix < len(modified) && jsonvalue.Equal(original[prefix], modified[prefix]) {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(original)-prefix && suffix < len(modified)-prefix &&
+		jsonvalue.Equal(original[len(original)-1-suffix], modified[len(modified)-1-suffix]) {
+		suffix++
+	}
+
+	originalChanged := original[prefix : len(original)-suffix]
+	modifiedChanged := modified[prefix : len(modified)-suffix]
+
+	common := min(len(originalChanged), len(modifiedChanged))
+	for i := range common {
+		diff(path+"/"+strconv.Itoa(prefix+i), originalChanged[i], modifiedChanged[i], patch)
+	}
+
+	// remove starting from the last element, so that the indexes of the
+	// elements yet to be removed do not change
+	for i := len(originalChanged) - 1; i >= common; i-- {
+		*patch = append(*patch, Operation{Op: OperationRemove, Path: path + "/" + strconv.Itoa(prefix+i)})
+	}
+
+	for i := common; i < len(modifiedChanged); i++ {
+		*patch = append(*patch, Operation{
+			Op:    OperationAdd,
+			Path:  path + "/" + strconv.Itoa(prefix+i),
+			Value: modifiedChanged[i],
+		})
+	}
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+// This package implements RFC 6902 JSON Patch documents. It can be used
+// both to compute the patch between two JSON documents and to apply a
+// patch to a JSON document.
+//
+// JSON documents are decoded into generic maps and slices, the package
+// doesn't need the reflection features that are missing from TinyGo.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// OperationType is the type of a JSON Patch operation.
+type OperationType string
+
+const (
+	OperationAdd     OperationType = "add"
+	OperationRemove  OperationType = "remove"
+	OperationReplace OperationType = "replace"
+	OperationMove    OperationType = "move"
+	OperationCopy    OperationType = "copy"
+	OperationTest    OperationType = "test"
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	// The operation to perform
+	Op OperationType
+	// JSON Pointer to the target location, see `NewPointer`
+	Path string
+	// JSON Pointer to the source location, used only by the `move` and
+	// `copy` operations
+	From string
+	// The value to add, replace or test. Ignored by the other operations
+	Value interface{}
+}
+
+// Patch is a JSON Patch document: a list of operations applied in order.
+type Patch []Operation
+
+// MarshalJSON serializes the operation. The `value` attribute is always
+// emitted for the operations that require it, even when it's `null`.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"op":`)
+	if err := writeJSON(&buf, string(o.Op)); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`,"path":`)
+	if err := writeJSON(&buf, o.Path); err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case OperationMove, OperationCopy:
+		buf.WriteString(`,"from":`)
+		if err := writeJSON(&buf, o.From); err != nil {
+			return nil, err
+		}
+	case OperationAdd, OperationReplace, OperationTest:
+		buf.WriteString(`,"value":`)
+		if err := writeJSON(&buf, o.Value); err != nil {
+			return nil, err
+		}
+	case OperationRemove:
+	}
+
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the operation. Numbers inside of `value` are
+// decoded as `json.Number` to not lose precision.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Op    OperationType   `json:"op"`
+		Path  string          `json:"path"`
+		From  string          `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	o.Op = raw.Op
+	o.Path = raw.Path
+	o.From = raw.From
+	o.Value = nil
+	if len(raw.Value) > 0 {
+		value, err := decode(raw.Value)
+		if err != nil {
+			return err
+		}
+		o.Value = value
+	}
+	return nil
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// EscapeToken escapes a single reference token of a JSON Pointer, as
+// described by RFC 6901. For example `app.kubernetes.io/name` becomes
+// `app.kubernetes.io~1name`.
+func EscapeToken(token string) string {
+	if !strings.ContainsAny(token, "~/") {
+		return token
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// UnescapeToken reverts `EscapeToken`.
+func UnescapeToken(token string) string {
+	if !strings.Contains(token, "~") {
+		return token
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// NewPointer builds a JSON Pointer out of the given, unescaped, reference
+// tokens. For example `NewPointer("metadata", "labels", "app/name")`
+// returns `/metadata/labels/app~1name`.
+func NewPointer(tokens ...string) string {
+	builder := strings.Builder{}
+	for _, token := range tokens {
+		builder.WriteString("/")
+		builder.WriteString(EscapeToken(token))
+	}
+	return builder.String()
+}
+
+// decode unmarshals a JSON document, numbers are kept as `json.Number`.
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return value, nil
+}
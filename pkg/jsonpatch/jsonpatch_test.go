@@ -0,0 +1,264 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/internal/jsonvalue"
+)
+
+func TestCreatePatch(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		original      string
+		modified      string
+		expectedPatch string
+	}{
+		"NoChanges": {
+			original:      `{"a":1,"b":[1,2,3]}`,
+			modified:      `{"b":[1,2,3],"a":1}`,
+			expectedPatch: `[]`,
+		},
+		"MapChanges": {
+			original:      `{"a":1,"b":{"c":"foo","d":true},"e":null}`,
+			modified:      `{"a":2,"b":{"c":"foo","f":false},"g":[]}`,
+			expectedPatch: `[{"op":"remove","path":"/e"},{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b/d"},{"op":"add","path":"/b/f","value":false},{"op":"add","path":"/g","value":[]}]`,
+		},
+		"EqualNumbers": {
+			original:      `{"a":1,"b":100,"c":[1.50]}`,
+			modified:      `{"a":1.0,"b":1e2,"c":[1.5]}`,
+			expectedPatch: `[]`,
+		},
+		"NullValue": {
+			original:      `{"a":1}`,
+			modified:      `{"a":null}`,
+			expectedPatch: `[{"op":"replace","path":"/a","value":null}]`,
+		},
+		"TypeChange": {
+			original:      `{"a":{"b":1}}`,
+			modified:      `{"a":[1]}`,
+			expectedPatch: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+		"EscapedKeys": {
+			original:      `{"metadata":{"labels":{"app.kubernetes.io/name":"foo","a~b":"1"}}}`,
+			modified:      `{"metadata":{"labels":{"app.kubernetes.io/name":"bar","a~b/c":"1"}}}`,
+			expectedPatch: `[{"op":"remove","path":"/metadata/labels/a~0b"},{"op":"replace","path":"/metadata/labels/app.kubernetes.io~1name","value":"bar"},{"op":"add","path":"/metadata/labels/a~0b~1c","value":"1"}]`,
+		},
+		"ArrayAppend": {
+			original:      `{"a":[1,2]}`,
+			modified:      `{"a":[1,2,3,4]}`,
+			expectedPatch: `[{"op":"add","path":"/a/2","value":3},{"op":"add","path":"/a/3","value":4}]`,
+		},
+		"ArrayPrepend": {
+			original:      `{"a":[1,2]}`,
+			modified:      `{"a":[0,1,2]}`,
+			expectedPatch: `[{"op":"add","path":"/a/0","value":0}]`,
+		},
+		"ArrayRemoveFromMiddle": {
+			original:      `{"a":[1,2,3,4]}`,
+			modified:      `{"a":[1,4]}`,
+			expectedPatch: `[{"op":"remove","path":"/a/2"},{"op":"remove","path":"/a/1"}]`,
+		},
+		"ArrayNestedChange": {
+			original:      `{"containers":[{"name":"a","image":"nginx"},{"name":"b","image":"busybox"}]}`,
+			modified:      `{"containers":[{"name":"a","image":"nginx"},{"name":"b","image":"busybox:1.36"}]}`,
+			expectedPatch: `[{"op":"replace","path":"/containers/1/image","value":"busybox:1.36"}]`,
+		},
+		"RootReplace": {
+			original:      `[1]`,
+			modified:      `{"a":1}`,
+			expectedPatch: `[{"op":"replace","path":"","value":{"a":1}}]`,
+		},
+		"LargeNumbers": {
+			original:      `{"a":9007199254740993}`,
+			modified:      `{"a":9007199254740995}`,
+			expectedPatch: `[{"op":"replace","path":"/a","value":9007199254740995}]`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			patch, err := CreatePatch([]byte(testCase.original), []byte(testCase.modified))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rawPatch, err := json.Marshal(patch)
+			if err != nil {
+				t.Fatalf("cannot serialize patch: %v", err)
+			}
+			if string(rawPatch) != testCase.expectedPatch {
+				t.Fatalf("unexpected patch: %s", rawPatch)
+			}
+
+			patched, err := Apply([]byte(testCase.original), patch)
+			if err != nil {
+				t.Fatalf("cannot apply patch: %v", err)
+			}
+			patchedValue, err := decode(patched)
+			if err != nil {
+				t.Fatalf("cannot decode patched document: %v", err)
+			}
+			expectedValue, err := decode([]byte(testCase.modified))
+			if err != nil {
+				t.Fatalf("cannot decode modified document: %v", err)
+			}
+			// numbers are compared by value, like CreatePatch does
+			if !jsonvalue.Equal(patchedValue, expectedValue) {
+				t.Fatalf("patched document %s is different from %s", patched, testCase.modified)
+			}
+		})
+	}
+}
+
+func TestCreatePatchFromChanges(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		document      string
+		original      string
+		modified      string
+		expectedPatch string
+	}{
+		"NoChanges": {
+			document:      `{"a":"2024-01-01T00:00:00Z","unknown":1}`,
+			original:      `{"a":"2024-01-01T00:00:00.000Z"}`,
+			modified:      `{"a":"2024-01-01T00:00:00.000Z"}`,
+			expectedPatch: `[]`,
+		},
+		"NestedChange": {
+			document:      `{"a":{"b":1,"unknown":true},"c":"2024-01-01T00:00:00Z"}`,
+			original:      `{"a":{"b":1},"c":"2024-01-01T00:00:00.000Z"}`,
+			modified:      `{"a":{"b":2},"c":"2024-01-01T00:00:00.000Z"}`,
+			expectedPatch: `[{"op":"replace","path":"/a/b","value":2}]`,
+		},
+		"AddAndRemove": {
+			document:      `{"a":1,"b":2,"unknown":3}`,
+			original:      `{"a":1,"b":2}`,
+			modified:      `{"a":1,"c":4}`,
+			expectedPatch: `[{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":4}]`,
+		},
+		"MadeUpZeroValue": {
+			document:      `{"a":{"unknown":1}}`,
+			original:      `{"a":{"name":""}}`,
+			modified:      `{"a":{"name":"foo"}}`,
+			expectedPatch: `[{"op":"add","path":"/a/name","value":"foo"}]`,
+		},
+		"ArrayInsertion": {
+			document:      `{"a":[{"b":1,"unknown":1},{"b":2,"unknown":2}]}`,
+			original:      `{"a":[{"b":1},{"b":2}]}`,
+			modified:      `{"a":[{"b":0},{"b":1},{"b":2}]}`,
+			expectedPatch: `[{"op":"add","path":"/a/0","value":{"b":0}}]`,
+		},
+		"ArrayElementChange": {
+			document:      `{"a":[{"b":1,"unknown":1},{"b":2,"unknown":2}]}`,
+			original:      `{"a":[{"b":1},{"b":2}]}`,
+			modified:      `{"a":[{"b":1},{"b":3}]}`,
+			expectedPatch: `[{"op":"replace","path":"/a/1/b","value":3}]`,
+		},
+		"TypeChange": {
+			document:      `{"a":{"b":1,"unknown":1}}`,
+			original:      `{"a":{"b":1}}`,
+			modified:      `{"a":[1]}`,
+			expectedPatch: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			patch, err := CreatePatchFromChanges([]byte(testCase.document), []byte(testCase.original), []byte(testCase.modified))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rawPatch, err := json.Marshal(patch)
+			if err != nil {
+				t.Fatalf("cannot serialize patch: %v", err)
+			}
+			if string(rawPatch) != testCase.expectedPatch {
+				t.Fatalf("unexpected patch: %s", rawPatch)
+			}
+			if _, err = Apply([]byte(testCase.document), patch); err != nil {
+				t.Fatalf("cannot apply patch: %v", err)
+			}
+		})
+	}
+
+	if _, err := CreatePatchFromChanges([]byte(`{`), []byte(`{}`), []byte(`{}`)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestCreatePatchInvalidDocument(t *testing.T) {
+	if _, err := CreatePatch([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := CreatePatch([]byte(`{}`), []byte(`{} {}`)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestApply(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		document         string
+		patch            string
+		expectedDocument string
+		expectedError    string
+	}{
+		"AddToArrayEnd": {
+			document:         `{"a":[1]}`,
+			patch:            `[{"op":"add","path":"/a/-","value":2}]`,
+			expectedDocument: `{"a":[1,2]}`,
+		},
+		"MoveAndCopy": {
+			document:         `{"a":{"b":1},"c":{}}`,
+			patch:            `[{"op":"move","from":"/a/b","path":"/c/b"},{"op":"copy","from":"/c","path":"/d"}]`,
+			expectedDocument: `{"a":{},"c":{"b":1},"d":{"b":1}}`,
+		},
+		"TestSucceeds": {
+			document:         `{"a":[1,{"b":"c"}]}`,
+			patch:            `[{"op":"test","path":"/a/1","value":{"b":"c"}}]`,
+			expectedDocument: `{"a":[1,{"b":"c"}]}`,
+		},
+		"TestFails": {
+			document:      `{"a":1}`,
+			patch:         `[{"op":"test","path":"/a","value":2}]`,
+			expectedError: "cannot apply operation #0 (test /a): test failed",
+		},
+		"RemoveMissingKey": {
+			document:      `{"a":1}`,
+			patch:         `[{"op":"remove","path":"/b"}]`,
+			expectedError: `cannot apply operation #0 (remove /b): key "b" not found`,
+		},
+		"IndexOutOfBounds": {
+			document:      `{"a":[1]}`,
+			patch:         `[{"op":"replace","path":"/a/1","value":2}]`,
+			expectedError: "cannot apply operation #0 (replace /a/1): array index 1 out of bounds",
+		},
+		"InvalidIndex": {
+			document:      `{"a":[1]}`,
+			patch:         `[{"op":"add","path":"/a/01","value":2}]`,
+			expectedError: `cannot apply operation #0 (add /a/01): invalid array index "01"`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			patch := Patch{}
+			if err := json.Unmarshal([]byte(testCase.patch), &patch); err != nil {
+				t.Fatalf("cannot decode patch: %v", err)
+			}
+
+			patched, err := Apply([]byte(testCase.document), patch)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(patched) != testCase.expectedDocument {
+				t.Fatalf("unexpected document: %s", patched)
+			}
+		})
+	}
+}
+
+func TestNewPointer(t *testing.T) {
+	pointer := NewPointer("metadata", "annotations", "example.com/a~b")
+	if pointer != "/metadata/annotations/example.com~1a~0b" {
+		t.Fatalf("unexpected pointer: %s", pointer)
+	}
+}
@@ -0,0 +1,114 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/kubewarden/policy-sdk-go/internal/jsonvalue"
+)
+
+// CreatePatchFromChanges computes the JSON Patch applying to `document` the
+// changes that transform `original` into `modified`.
+//
+// `original` and `modified` are usually the object of the request before
+// and after being mutated through the Kubernetes Go types, which drop the
+// fields they don't know and can serialize some values differently, like
+// timestamps. Only the values changed between `original` and `modified`
+// are patched: the rest of `document`, including its unknown fields, is
+// left untouched.
+func CreatePatchFromChanges(document, original, modified []byte) (Patch, error) {
+	documentValue, err := decode(document)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode document: %w", err)
+	}
+	originalDoc, err := decode(original)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode original document: %w", err)
+	}
+	modifiedDoc, err := decode(modified)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode modified document: %w", err)
+	}
+
+	patch := Patch{}
+	diff("", documentValue, mergeChanges(documentValue, originalDoc, modifiedDoc), &patch)
+	return patch, nil
+}
+
+// mergeChanges returns `document` with the changes between `original` and
+// `modified` applied.
+func mergeChanges(document, original, modified interface{}) interface{} {
+	if jsonvalue.Equal(original, modified) {
+		return document
+	}
+
+	switch originalValue := original.(type) {
+	case map[string]interface{}:
+		documentValue, documentOk := document.(map[string]interface{})
+		modifiedValue, modifiedOk := modified.(map[string]interface{})
+		if documentOk && modifiedOk {
+			return mergeObjects(documentValue, originalValue, modifiedValue)
+		}
+	case []interface{}:
+		documentValue, documentOk := document.([]interface{})
+		modifiedValue, modifiedOk := modified.([]interface{})
+		if documentOk && modifiedOk && len(documentValue) == len(originalValue) {
+			return mergeArrays(documentValue, originalValue, modifiedValue)
+		}
+	}
+	return modified
+}
+
+func mergeObjects(document, original, modified map[string]interface{}) map[string]interface{} {
+	merged := maps.Clone(document)
+	for key := range original {
+		if _, found := modified[key]; !found {
+			delete(merged, key)
+		}
+	}
+
+	for key, modifiedValue := range modified {
+		originalValue, inOriginal := original[key]
+		documentValue, inDocument := document[key]
+		switch {
+		case !inOriginal:
+			merged[key] = modifiedValue
+		case inDocument:
+			merged[key] = mergeChanges(documentValue, originalValue, modifiedValue)
+		case !jsonvalue.Equal(originalValue, modifiedValue):
+			// the value has been made up while serializing `original`,
+			// like the zero values of the fields without `omitempty`
+			merged[key] = modifiedValue
+		}
+	}
+	return merged
+}
+
+// mergeArrays merges the arrays element by element, after skipping their
+// common prefix and suffix, like diffArrays does. `document` and `original`
+// have the same length.
+func mergeArrays(document, original, modified []interface{}) []interface{} {
+	prefix := 0
+	for prefix < len(original) && prefix < len(modified) && jsonvalue.Equal(original[prefix], modified[prefix]) {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(original)-prefix && suffix < len(modified)-prefix &&
+		jsonvalue.Equal(original[len(original)-1-suffix], modified[len(modified)-1-suffix]) {
+		suffix++
+	}
+
+	merged := make([]interface{}, 0, len(modified))
+	merged = append(merged, document[:prefix]...)
+	originalChanged := original[prefix : len(original)-suffix]
+	modifiedChanged := modified[prefix : len(modified)-suffix]
+	for i, modifiedValue := range modifiedChanged {
+		if i < len(originalChanged) {
+			merged = append(merged, mergeChanges(document[prefix+i], originalChanged[i], modifiedValue))
+			continue
+		}
+		merged = append(merged, modifiedValue)
+	}
+	return append(merged, document[len(document)-suffix:]...)
+}
@@ -0,0 +1,241 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const settingsSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "mode": {"type": "string", "enum": ["enforce", "monitor"]},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+    "ratio": {"type": "number"},
+    "name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+    "registries": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "properties": {"host": {"type": "string"}},
+        "required": ["host"],
+        "additionalProperties": false
+      }
+    },
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "proxy": {"type": ["string", "null"]}
+  },
+  "required": ["mode"],
+  "additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	schema := MustParse([]byte(settingsSchema))
+
+	for description, testCase := range map[string]struct {
+		document         string
+		expectedProblems []string
+	}{
+		"Valid": {
+			document: `{"mode": "enforce", "replicas": 3, "ratio": 0.5, "name": "abc",
+				"registries": [{"host": "docker.io"}], "labels": {"app": "nginx"}}`,
+		},
+		"Null": {
+			document: `{"mode": "enforce", "proxy": null}`,
+		},
+		"IntegerAsNumber": {
+			document: `{"mode": "monitor", "ratio": 1}`,
+		},
+		"MissingRequiredField": {
+			document:         `{}`,
+			expectedProblems: []string{"mode: is required"},
+		},
+		"WrongTypes": {
+			document: `{"mode": 1, "replicas": 1.5, "labels": {"app": true}, "proxy": 1}`,
+			expectedProblems: []string{
+				"labels.app: must be of type string, got boolean",
+				"mode: must be of type string, got integer",
+				"proxy: must be of type string or null, got integer",
+				"replicas: must be of type integer, got number",
+			},
+		},
+		"Constraints": {
+			document: `{"mode": "audit", "replicas": 11, "name": "A", "registries": []}`,
+			expectedProblems: []string{
+				`mode: must be one of ["enforce", "monitor"]`,
+				"name: must be at least 2 characters long",
+				`name: must match the pattern "^[a-z]+$"`,
+				"registries: must have at least 1 items",
+				"replicas: must be less than or equal to 10",
+			},
+		},
+		"UnknownFields": {
+			document: `{"mode": "enforce", "foo": 1, "registries": [{"host": "docker.io", "port": 5000}, {}]}`,
+			expectedProblems: []string{
+				"foo: unknown field",
+				"registries[0].port: unknown field",
+				"registries[1].host: is required",
+			},
+		},
+		"NotAnObject": {
+			document:         `[]`,
+			expectedProblems: []string{"(root): must be of type object, got array"},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			err := schema.Validate([]byte(testCase.document))
+
+			var problems []string
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				problems = validationErr.Problems
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(testCase.expectedProblems, problems); diff != "" {
+				t.Fatalf("unexpected problems:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	schema := &Schema{Type: TypeString, Pattern: "["}
+	if err := schema.Validate([]byte(`"a"`)); err == nil {
+		t.Fatalf("expected an error for the invalid pattern")
+	}
+	if err := schema.Validate([]byte(`{`)); err == nil {
+		t.Fatalf("expected an error for the invalid document")
+	}
+}
+
+func TestBooleanSchemas(t *testing.T) {
+	schema := MustParse([]byte(`{"properties": {"any": true, "none": false}}`))
+
+	if err := schema.Validate([]byte(`{"any": [1, "a"]}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := schema.Validate([]byte(`{"none": 1}`))
+	if err == nil || err.Error() != "none: unknown field" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	serialized, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(serialized) != `{"properties":{"any":{},"none":false}}` {
+		t.Fatalf("unexpected serialization: %s", serialized)
+	}
+}
+
+func TestNotSchemas(t *testing.T) {
+	document := `{"additionalProperties":{"not":{"required":["a"]}}}`
+	schema := MustParse([]byte(document))
+
+	serialized, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(serialized) != document {
+		t.Fatalf("unexpected serialization: %s", serialized)
+	}
+
+	if err = schema.Validate([]byte(`{"x": {"b": 1}}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = schema.Validate([]byte(`{"x": {"a": 1}}`))
+	if err == nil || err.Error() != "x: value is not allowed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNullableTypes(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		schema             string
+		expectedType       string
+		expectedNullable   bool
+		expectedSerialized string
+	}{
+		"Single": {
+			schema:             `{"type": "string"}`,
+			expectedType:       TypeString,
+			expectedSerialized: `{"type":"string"}`,
+		},
+		"Nullable": {
+			schema:             `{"type": ["null", "integer"], "minimum": 1}`,
+			expectedType:       TypeInteger,
+			expectedNullable:   true,
+			expectedSerialized: `{"type":["integer","null"],"minimum":1}`,
+		},
+		"OnlyNull": {
+			schema:             `{"type": ["null"]}`,
+			expectedType:       TypeNull,
+			expectedSerialized: `{"type":"null"}`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			schema, err := Parse([]byte(testCase.schema))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema.Type != testCase.expectedType || schema.Nullable != testCase.expectedNullable {
+				t.Fatalf("unexpected type: %q, nullable: %t", schema.Type, schema.Nullable)
+			}
+
+			serialized, err := json.Marshal(schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(serialized) != testCase.expectedSerialized {
+				t.Fatalf("unexpected serialization: %s", serialized)
+			}
+		})
+	}
+}
+
+func TestPatternIsCompiledOnce(t *testing.T) {
+	schema := &Schema{Type: TypeString, Pattern: "^[a-z]+$"}
+	if err := schema.Validate([]byte(`"abc"`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	compiled := schema.pattern
+	if compiled == nil {
+		t.Fatalf("expected the pattern to be cached")
+	}
+	if err := schema.Validate([]byte(`"1"`)); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if schema.pattern != compiled {
+		t.Fatalf("expected the cached pattern to be reused")
+	}
+
+	schema.Pattern = "^[0-9]+$"
+	if err := schema.Validate([]byte(`"1"`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, schema := range []string{`{"type": 1}`, `{"type": ["string", "integer"]}`} {
+		if _, err := Parse([]byte(schema)); err == nil {
+			t.Fatalf("expected an error for %s", schema)
+		}
+	}
+
+	schema, err := Parse([]byte(`{"type": "integer", "enum": [1, 2], "default": 1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = schema.Validate([]byte(`2`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = schema.Validate([]byte(`3`)); err == nil || err.Error() != "(root): must be one of [1, 2]" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
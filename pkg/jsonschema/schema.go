@@ -0,0 +1,189 @@
+// This package implements the subset of JSON Schema used to describe the
+// settings of the policies. The schemas can be generated from the Go type
+// of the settings using the `cmd/settings-schema` tool, and can be used to
+// validate the settings provided by the users from within the policy.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Draft is the JSON Schema dialect of the schemas produced by this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Types of the values that can be described by a Schema.
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// Schema is a JSON Schema document.
+//
+// The boolean schemas `true` and `false` are decoded respectively into an
+// empty Schema, which accepts everything, and into a Schema that accepts
+// nothing, see False. The latter is serialized back as `false`.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type is the type of the value, one of the `Type*` constants. Any type
+	// is accepted when empty.
+	Type string `json:"type,omitempty"`
+	// Nullable accepts `null` besides the values of Type. It's serialized
+	// as the `[<type>, "null"]` list of types.
+	Nullable bool          `json:"-"`
+	Enum     []interface{} `json:"enum,omitempty"`
+	Default  interface{}   `json:"default,omitempty"`
+
+	// Objects
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// Arrays
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	// Strings
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	// Numbers
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Not is a schema the value must not be valid against
+	Not *Schema `json:"not,omitempty"`
+
+	// pattern caches the compiled Pattern
+	pattern *regexp.Regexp
+	// falseSchema marks the `false` boolean schema, see False
+	falseSchema bool
+}
+
+// False returns a Schema that doesn't accept any value. It's commonly used
+// as `AdditionalProperties` to forbid unknown keys.
+func False() *Schema {
+	return &Schema{Not: &Schema{}, falseSchema: true}
+}
+
+// Parse decodes a JSON Schema document.
+func Parse(data []byte) (*Schema, error) {
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("cannot decode JSON Schema: %w", err)
+	}
+	return schema, nil
+}
+
+// MustParse is like Parse, but it panics when the schema cannot be
+// decoded. It's meant to be used with schemas embedded inside of the
+// policy.
+func MustParse(data []byte) *Schema {
+	schema, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// MarshalJSON encodes the schema, including the `false` boolean one and
+// the nullable types.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	if isFalse(&s) {
+		return []byte("false"), nil
+	}
+
+	type plain Schema
+	if !s.Nullable || s.Type == "" || s.Type == TypeNull {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		Type []string `json:"type"`
+		plain
+	}{
+		Type:  []string{s.Type, TypeNull},
+		plain: plain(s),
+	})
+}
+
+// UnmarshalJSON decodes the schema, including the boolean ones.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = *False()
+		return nil
+	}
+
+	type plain Schema
+	decoded := struct {
+		Type json.RawMessage `json:"type"`
+		plain
+	}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+	*s = Schema(decoded.plain)
+	if len(decoded.Type) == 0 {
+		return nil
+	}
+	return s.unmarshalType(decoded.Type)
+}
+
+// unmarshalType decodes the `type` keyword, which is either a single type
+// or a list made of a type and, optionally, `null`.
+func (s *Schema) unmarshalType(data []byte) error {
+	if err := json.Unmarshal(data, &s.Type); err == nil {
+		return nil
+	}
+
+	var types []string
+	if err := json.Unmarshal(data, &types); err != nil {
+		return fmt.Errorf("invalid type: %w", err)
+	}
+	s.Type = ""
+	for _, schemaType := range types {
+		switch {
+		case schemaType == TypeNull:
+			s.Nullable = true
+		case s.Type == "":
+			s.Type = schemaType
+		default:
+			return errors.New("invalid type: only a type and null can be listed")
+		}
+	}
+	if s.Type == "" && s.Nullable {
+		s.Type, s.Nullable = TypeNull, false
+	}
+	return nil
+}
+
+// compiledPattern returns the compiled Pattern, which is cached inside of
+// the schema.
+func (s *Schema) compiledPattern() (*regexp.Regexp, error) {
+	if s.pattern == nil || s.pattern.String() != s.Pattern {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.pattern = pattern
+	}
+	return s.pattern, nil
+}
@@ -0,0 +1,273 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+)
+
+// ValidationError reports all the problems found while validating a
+// document against a Schema.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the JSON document against the schema. All the problems
+// found are reported by the returned ValidationError. Other errors are
+// returned when the document is not valid JSON, or when the schema has an
+// invalid `pattern`.
+func (s *Schema) Validate(document []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("cannot decode document: %w", err)
+	}
+
+	v := validator{}
+	if err := v.validate(s, value, rawjson.Path{}); err != nil {
+		return err
+	}
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) report(path rawjson.Path, format string, args ...interface{}) {
+	location := path.String()
+	if location == "" {
+		location = "(root)"
+	}
+	v.problems = append(v.problems, location+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validate(schema *Schema, value interface{}, path rawjson.Path) error {
+	if schema.Not != nil {
+		not := validator{}
+		if err := not.validate(schema.Not, value, path); err != nil {
+			return err
+		}
+		if len(not.problems) == 0 {
+			v.report(path, "value is not allowed")
+			return nil
+		}
+	}
+
+	if schema.Type != "" && !hasType(value, schema.Type) && (!schema.Nullable || value != nil) {
+		v.report(path, "must be of type %s, got %s", formatType(schema), typeOf(value))
+		return nil
+	}
+
+	if len(schema.Enum) > 0 && !contains(schema.Enum, value) {
+		v.report(path, "must be one of %s", formatEnum(schema.Enum))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return v.validateObject(schema, typed, path)
+	case []interface{}:
+		return v.validateArray(schema, typed, path)
+	case string:
+		return v.validateString(schema, typed, path)
+	case json.Number:
+		v.validateNumber(schema, typed, path)
+	}
+	return nil
+}
+
+func (v *validator) validateObject(schema *Schema, object map[string]interface{}, path rawjson.Path) error {
+	for _, key := range schema.Required {
+		if _, found := object[key]; !found {
+			v.report(path.Key(key), "is required")
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, found := schema.Properties[key]
+		if !found {
+			property = schema.AdditionalProperties
+		}
+		if property == nil {
+			continue
+		}
+		if isFalse(property) {
+			v.report(path.Key(key), "unknown field")
+			continue
+		}
+		if err := v.validate(property, object[key], path.Key(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateArray(schema *Schema, array []interface{}, path rawjson.Path) error {
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		v.report(path, "must have at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		v.report(path, "must have at most %d items", *schema.MaxItems)
+	}
+	if schema.Items == nil {
+		return nil
+	}
+	for i, item := range array {
+		if err := v.validate(schema.Items, item, path.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateString(schema *Schema, value string, path rawjson.Path) error {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.report(path, "must be at least %d characters long", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.report(path, "must be at most %d characters long", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		pattern, err := schema.compiledPattern()
+		if err != nil {
+			return fmt.Errorf("invalid pattern of %q: %w", path.String(), err)
+		}
+		if !pattern.MatchString(value) {
+			v.report(path, "must match the pattern %q", schema.Pattern)
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateNumber(schema *Schema, value json.Number, path rawjson.Path) {
+	number, err := value.Float64()
+	if err != nil {
+		v.report(path, "invalid number %s", value)
+		return
+	}
+	if schema.Minimum != nil && number < *schema.Minimum {
+		v.report(path, "must be greater than or equal to %s", formatFloat(*schema.Minimum))
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		v.report(path, "must be less than or equal to %s", formatFloat(*schema.Maximum))
+	}
+}
+
+// isFalse tells whether the schema is the `false` boolean schema.
+func isFalse(schema *Schema) bool {
+	return schema.falseSchema
+}
+
+func hasType(value interface{}, schemaType string) bool {
+	actual := typeOf(value)
+	if schemaType == TypeNumber && actual == TypeInteger {
+		return true
+	}
+	return actual == schemaType
+}
+
+func formatType(schema *Schema) string {
+	if schema.Nullable {
+		return schema.Type + " or " + TypeNull
+	}
+	return schema.Type
+}
+
+func typeOf(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case string:
+		return TypeString
+	case json.Number:
+		if number, err := typed.Float64(); err == nil && number == math.Trunc(number) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case []interface{}:
+		return TypeArray
+	default:
+		return TypeObject
+	}
+}
+
+func contains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if equal(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares two JSON values. Numbers are compared by value, regardless
+// of their Go type.
+func equal(a, b interface{}) bool {
+	aNumber, aIsNumber := toFloat(a)
+	bNumber, bIsNumber := toFloat(b)
+	if aIsNumber || bIsNumber {
+		return aIsNumber && bIsNumber && aNumber == bNumber
+	}
+
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case json.Number:
+		number, err := typed.Float64()
+		return number, err == nil
+	case float64:
+		return typed, true
+	case float32:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case int32:
+		return float64(typed), true
+	default:
+		return 0, false
+	}
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		// values decoded from JSON can always be serialized
+		raw, _ := json.Marshal(value)
+		values = append(values, string(raw))
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func formatFloat(value float64) string {
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
@@ -0,0 +1,78 @@
+package labels
+
+import (
+	"fmt"
+	"slices"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// The operators of the `matchExpressions` of a LabelSelector.
+const (
+	LabelSelectorOpIn           = "In"
+	LabelSelectorOpNotIn        = "NotIn"
+	LabelSelectorOpExists       = "Exists"
+	LabelSelectorOpDoesNotExist = "DoesNotExist"
+)
+
+// LabelSelectorAsSelector converts a LabelSelector into a Selector. Like
+// Kubernetes does, a nil LabelSelector matches nothing, while an empty one
+// matches everything.
+func LabelSelectorAsSelector(labelSelector *metav1.LabelSelector) (Selector, error) {
+	if labelSelector == nil {
+		return Nothing(), nil
+	}
+	if len(labelSelector.MatchLabels)+len(labelSelector.MatchExpressions) == 0 {
+		return Everything(), nil
+	}
+
+	requirements := make([]Requirement, 0, len(labelSelector.MatchLabels)+len(labelSelector.MatchExpressions))
+	for key, value := range labelSelector.MatchLabels {
+		requirement, err := NewRequirement(key, Equals, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, *requirement)
+	}
+	for _, expression := range labelSelector.MatchExpressions {
+		if expression == nil {
+			continue
+		}
+		operator := stringValue(expression.Operator)
+		var op Operator
+		switch operator {
+		case LabelSelectorOpIn:
+			op = In
+		case LabelSelectorOpNotIn:
+			op = NotIn
+		case LabelSelectorOpExists:
+			op = Exists
+		case LabelSelectorOpDoesNotExist:
+			op = DoesNotExist
+		default:
+			return nil, fmt.Errorf("%q is not a valid label selector operator", operator)
+		}
+		requirement, err := NewRequirement(stringValue(expression.Key), op, slices.Clone(expression.Values))
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, *requirement)
+	}
+	return NewSelector().Add(requirements...), nil
+}
+
+// MatchesLabelSelector tells whether the labels match the LabelSelector.
+func MatchesLabelSelector(labelSelector *metav1.LabelSelector, labels map[string]string) (bool, error) {
+	selector, err := LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(Set(labels)), nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
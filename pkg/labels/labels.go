@@ -0,0 +1,63 @@
+// This package evaluates Kubernetes label selectors inside of the policies.
+// It's a port of the `labels` package of Kubernetes apimachinery, with the
+// same syntax, semantics and error messages, which doesn't rely on any
+// feature missing from TinyGo.
+//
+// Selectors can be parsed from their string representation:
+//
+//	selector, err := labels.Parse("app in (web,api),!legacy")
+//
+// or built from the `LabelSelector` objects used by the Kubernetes
+// resources, and by the settings of the policies:
+//
+//	selector, err := labels.LabelSelectorAsSelector(settings.Selector)
+//
+// and are evaluated against the labels of the objects:
+//
+//	selector.Matches(labels.Set(pod.Metadata.Labels))
+package labels
+
+import (
+	"sort"
+	"strings"
+)
+
+// Labels allows to inspect a set of labels, regardless of how they are
+// stored.
+type Labels interface {
+	// Has returns whether the label exists.
+	Has(label string) bool
+	// Get returns the value of the label, or the empty string.
+	Get(label string) string
+}
+
+// Set is a map of labels, it implements Labels.
+type Set map[string]string
+
+// String returns the labels in the `key=value` form, sorted by key and
+// separated by commas.
+func (ls Set) String() string {
+	selector := make([]string, 0, len(ls))
+	for key, value := range ls {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ",")
+}
+
+// Has returns whether the label exists.
+func (ls Set) Has(label string) bool {
+	_, exists := ls[label]
+	return exists
+}
+
+// Get returns the value of the label, or the empty string.
+func (ls Set) Get(label string) string {
+	return ls[label]
+}
+
+// AsSelector returns a selector matching the objects having all the labels
+// of the set. The labels are not validated, see ValidatedSelectorFromSet.
+func (ls Set) AsSelector() Selector {
+	return SelectorFromSet(ls)
+}
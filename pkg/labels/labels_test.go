@@ -0,0 +1,236 @@
+package labels
+
+import (
+	"testing"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParse(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		selector         string
+		expectedString   string
+		expectedError    string
+		matchingLabels   []Set
+		unmatchingLabels []Set
+	}{
+		"Empty": {
+			selector:       "",
+			expectedString: "",
+			matchingLabels: []Set{{}, {"app": "web"}},
+		},
+		"Equality": {
+			selector:         "app=web,tier==frontend,env!=prod",
+			expectedString:   "app=web,env!=prod,tier==frontend",
+			matchingLabels:   []Set{{"app": "web", "tier": "frontend"}, {"app": "web", "tier": "frontend", "env": "dev"}},
+			unmatchingLabels: []Set{{"app": "web"}, {"app": "web", "tier": "frontend", "env": "prod"}},
+		},
+		"SetBased": {
+			selector:         "app in (web, api),!legacy, env notin (prod)",
+			expectedString:   "app in (api,web),env notin (prod),!legacy",
+			matchingLabels:   []Set{{"app": "api"}, {"app": "web", "env": "dev"}},
+			unmatchingLabels: []Set{{"app": "db"}, {"app": "web", "legacy": ""}, {"app": "web", "env": "prod"}, {}},
+		},
+		"Exists": {
+			selector:         "app,prefix.example.com/tier",
+			expectedString:   "app,prefix.example.com/tier",
+			matchingLabels:   []Set{{"app": "", "prefix.example.com/tier": "a"}},
+			unmatchingLabels: []Set{{"app": ""}},
+		},
+		"EmptyValues": {
+			selector:         "app=,tier in (a,)",
+			expectedString:   "app=,tier in (,a)",
+			matchingLabels:   []Set{{"app": "", "tier": ""}, {"app": "", "tier": "a"}},
+			unmatchingLabels: []Set{{"app": "web", "tier": "a"}},
+		},
+		"NumericComparison": {
+			selector:         "replicas>1,replicas<10",
+			expectedString:   "replicas>1,replicas<10",
+			matchingLabels:   []Set{{"replicas": "5"}},
+			unmatchingLabels: []Set{{"replicas": "1"}, {"replicas": "10"}, {"replicas": "many"}, {}},
+		},
+		"InAsValue": {
+			selector:         "app=in",
+			expectedString:   "app=in",
+			matchingLabels:   []Set{{"app": "in"}},
+			unmatchingLabels: []Set{{"app": "notin"}},
+		},
+		"MissingValues": {
+			selector:      "app in web",
+			expectedError: "unable to parse requirement: found 'web' expected: '('",
+		},
+		"DoubleComma": {
+			selector:      "app=web,,tier=frontend",
+			expectedError: "found ',', expected: identifier after ','",
+		},
+		"TrailingOperator": {
+			selector:      "app=web tier",
+			expectedError: "found 'tier', expected: ',' or 'end of string'",
+		},
+		"UnknownOperator": {
+			selector:      "app ~ web",
+			expectedError: "unable to parse requirement: found '~', expected: in, notin, =, ==, !=, gt, lt",
+		},
+		"InvalidKey": {
+			selector: "-app=web",
+			expectedError: `unable to parse requirement: <nil>: Invalid value: "-app": name part must consist of ` +
+				`alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character ` +
+				`(e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is ` +
+				`'([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+		},
+		"InvalidValue": {
+			selector: "app=-web",
+			expectedError: `unable to parse requirement: values[0][app]: Invalid value: "-web": a valid label must be ` +
+				`an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with ` +
+				`an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is ` +
+				`'(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		"NotAnInteger": {
+			selector: "replicas>a",
+			expectedError: `unable to parse requirement: values[0]: Invalid value: "a": ` +
+				`for 'Gt', 'Lt' operators, the value must be an integer`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			selector, err := Parse(testCase.selector)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if selector.String() != testCase.expectedString {
+				t.Fatalf("unexpected string: %s", selector.String())
+			}
+			for _, labels := range testCase.matchingLabels {
+				if !selector.Matches(labels) {
+					t.Fatalf("%q should match %v", testCase.selector, labels)
+				}
+			}
+			for _, labels := range testCase.unmatchingLabels {
+				if selector.Matches(labels) {
+					t.Fatalf("%q should not match %v", testCase.selector, labels)
+				}
+			}
+
+			reparsed, err := Parse(selector.String())
+			if err != nil || reparsed.String() != selector.String() {
+				t.Fatalf("cannot parse %q back: %v", selector.String(), err)
+			}
+		})
+	}
+}
+
+func TestNewRequirement(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		key           string
+		operator      Operator
+		values        []string
+		expectedError string
+	}{
+		"Valid": {
+			key:      "app.kubernetes.io/name",
+			operator: In,
+			values:   []string{"web"},
+		},
+		"EmptySet": {
+			key:           "app",
+			operator:      NotIn,
+			values:        []string{},
+			expectedError: "values: Invalid value: []string{}: for 'in', 'notin' operators, values set can't be empty",
+		},
+		"ValuesForExists": {
+			key:           "app",
+			operator:      Exists,
+			values:        []string{"web"},
+			expectedError: `values: Invalid value: []string{"web"}: values set must be empty for exists and does not exist`,
+		},
+		"UnsupportedOperator": {
+			key:      "app",
+			operator: "like",
+			expectedError: `operator: Unsupported value: "like": supported values: "in", "notin", "=", "==", "!=", ` +
+				`"gt", "lt", "exists", "!"`,
+		},
+		"MultipleErrors": {
+			key:      "Example.com/app",
+			operator: Equals,
+			values:   []string{"a", "b"},
+			expectedError: `[key: Invalid value: "Example.com/app": prefix part a lowercase RFC 1123 subdomain must ` +
+				`consist of lower case alphanumeric characters, '-' or '.', and must start and end with an ` +
+				`alphanumeric character (e.g. 'example.com', regex used for validation is ` +
+				`'[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*'), ` +
+				`values: Invalid value: []string{"a", "b"}: exact-match compatibility requires one single value]`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			_, err := NewRequirement(testCase.key, testCase.operator, testCase.values)
+			if testCase.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLabelSelectorAsSelector(t *testing.T) {
+	key, in, exists := "tier", LabelSelectorOpIn, LabelSelectorOpExists
+	labelSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web"},
+		MatchExpressions: []*metav1.LabelSelectorRequirement{
+			{Key: &key, Operator: &in, Values: []string{"frontend", "backend"}},
+			{Key: &key, Operator: &exists},
+		},
+	}
+
+	selector, err := LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if selector.String() != "app=web,tier in (backend,frontend),tier" {
+		t.Fatalf("unexpected selector: %s", selector.String())
+	}
+	if !selector.Matches(Set{"app": "web", "tier": "backend"}) || selector.Matches(Set{"app": "web"}) {
+		t.Fatalf("unexpected matches for %s", selector.String())
+	}
+	if value, found := selector.RequiresExactMatch("app"); !found || value != "web" {
+		t.Fatalf("unexpected exact match: %s, %t", value, found)
+	}
+
+	matches, err := MatchesLabelSelector(&metav1.LabelSelector{}, map[string]string{"app": "db"})
+	if err != nil || !matches {
+		t.Fatalf("the empty selector should match everything: %t, %v", matches, err)
+	}
+	matches, err = MatchesLabelSelector(nil, map[string]string{"app": "db"})
+	if err != nil || matches {
+		t.Fatalf("the nil selector should match nothing: %t, %v", matches, err)
+	}
+
+	invalid := "Like"
+	labelSelector.MatchExpressions[0].Operator = &invalid
+	if _, err = LabelSelectorAsSelector(labelSelector); err == nil || err.Error() != `"Like" is not a valid label selector operator` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSelectorFromSet(t *testing.T) {
+	selector := Set{"tier": "frontend", "app": "web"}.AsSelector()
+	if selector.String() != "app=web,tier=frontend" {
+		t.Fatalf("unexpected selector: %s", selector.String())
+	}
+	if !selector.Matches(Set{"app": "web", "tier": "frontend", "env": "prod"}) {
+		t.Fatalf("the selector should match a superset of its labels")
+	}
+
+	if _, err := ValidatedSelectorFromSet(Set{"app": "-web"}); err == nil {
+		t.Fatalf("expected an error for the invalid value")
+	}
+}
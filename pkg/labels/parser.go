@@ -0,0 +1,426 @@
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// token is the lexical token of the selector syntax.
+type token int
+
+const (
+	errorToken token = iota
+	endOfStringToken
+	closedParToken
+	commaToken
+	doesNotExistToken
+	doubleEqualsToken
+	equalsToken
+	greaterThanToken
+	identifierToken
+	inToken
+	lessThanToken
+	notEqualsToken
+	notInToken
+	openParToken
+)
+
+// nilPath is the path reported by the errors of the keys found by the
+// parser, like Kubernetes does.
+const nilPath = "<nil>"
+
+// stringToToken maps the keywords and the special symbols to their tokens.
+func stringToToken(literal string) (token, bool) {
+	switch literal {
+	case ")":
+		return closedParToken, true
+	case ",":
+		return commaToken, true
+	case "!":
+		return doesNotExistToken, true
+	case "==":
+		return doubleEqualsToken, true
+	case "=":
+		return equalsToken, true
+	case ">":
+		return greaterThanToken, true
+	case "in":
+		return inToken, true
+	case "<":
+		return lessThanToken, true
+	case "!=":
+		return notEqualsToken, true
+	case "notin":
+		return notInToken, true
+	case "(":
+		return openParToken, true
+	default:
+		return errorToken, false
+	}
+}
+
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n'
+}
+
+func isSpecialSymbol(ch byte) bool {
+	switch ch {
+	case '=', '!', '(', ')', ',', '>', '<':
+		return true
+	default:
+		return false
+	}
+}
+
+// lexer splits the selector into tokens.
+type lexer struct {
+	s   string
+	pos int
+}
+
+// read returns the next character, or 0 at the end of the string.
+func (l *lexer) read() byte {
+	if l.pos < len(l.s) {
+		b := l.s[l.pos]
+		l.pos++
+		return b
+	}
+	return 0
+}
+
+func (l *lexer) unread() {
+	l.pos--
+}
+
+func (l *lexer) scanIDOrKeyword() (token, string) {
+	var buffer []byte
+	for {
+		ch := l.read()
+		if ch == 0 {
+			break
+		}
+		if isSpecialSymbol(ch) || isWhitespace(ch) {
+			l.unread()
+			break
+		}
+		buffer = append(buffer, ch)
+	}
+
+	literal := string(buffer)
+	if tok, ok := stringToToken(literal); ok {
+		return tok, literal
+	}
+	return identifierToken, literal
+}
+
+// scanSpecialSymbol scans the longest sequence of special symbols forming
+// a valid token.
+func (l *lexer) scanSpecialSymbol() (token, string) {
+	lastToken, lastLiteral := errorToken, ""
+	var buffer []byte
+	for {
+		ch := l.read()
+		if ch == 0 {
+			break
+		}
+		if !isSpecialSymbol(ch) {
+			l.unread()
+			break
+		}
+		buffer = append(buffer, ch)
+		if tok, ok := stringToToken(string(buffer)); ok {
+			lastToken, lastLiteral = tok, string(buffer)
+		} else if lastToken != errorToken {
+			l.unread()
+			break
+		}
+	}
+
+	if lastToken == errorToken {
+		return errorToken, fmt.Sprintf("error expected: keyword found '%s'", buffer)
+	}
+	return lastToken, lastLiteral
+}
+
+func (l *lexer) skipWhiteSpaces(ch byte) byte {
+	for isWhitespace(ch) {
+		ch = l.read()
+	}
+	return ch
+}
+
+// lex returns the next token and its literal.
+func (l *lexer) lex() (token, string) {
+	ch := l.skipWhiteSpaces(l.read())
+	switch {
+	case ch == 0:
+		return endOfStringToken, ""
+	case isSpecialSymbol(ch):
+		l.unread()
+		return l.scanSpecialSymbol()
+	default:
+		l.unread()
+		return l.scanIDOrKeyword()
+	}
+}
+
+type scannedItem struct {
+	tok     token
+	literal string
+}
+
+// parserContext tells whether the parser is looking for values, in which
+// case the `in` and `notin` keywords are identifiers.
+type parserContext int
+
+const (
+	keyAndOperator parserContext = iota
+	values
+)
+
+type parser struct {
+	l            *lexer
+	scannedItems []scannedItem
+	position     int
+}
+
+func (p *parser) lookahead(context parserContext) (token, string) {
+	item := p.scannedItems[p.position]
+	if context == values && (item.tok == inToken || item.tok == notInToken) {
+		return identifierToken, item.literal
+	}
+	return item.tok, item.literal
+}
+
+func (p *parser) consume(context parserContext) (token, string) {
+	tok, literal := p.lookahead(context)
+	p.position++
+	return tok, literal
+}
+
+func (p *parser) scan() {
+	for {
+		tok, literal := p.l.lex()
+		p.scannedItems = append(p.scannedItems, scannedItem{tok: tok, literal: literal})
+		if tok == endOfStringToken {
+			break
+		}
+	}
+}
+
+func (p *parser) parse() ([]Requirement, error) {
+	p.scan()
+
+	var requirements []Requirement
+	for {
+		tok, literal := p.lookahead(values)
+		switch tok {
+		case identifierToken, doesNotExistToken:
+			requirement, err := p.parseRequirement()
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse requirement: %w", err)
+			}
+			requirements = append(requirements, *requirement)
+
+			next, nextLiteral := p.consume(values)
+			switch next {
+			case endOfStringToken:
+				return requirements, nil
+			case commaToken:
+				following, followingLiteral := p.lookahead(values)
+				if following != identifierToken && following != doesNotExistToken {
+					return nil, fmt.Errorf("found '%s', expected: identifier after ','", followingLiteral)
+				}
+			default:
+				return nil, fmt.Errorf("found '%s', expected: ',' or 'end of string'", nextLiteral)
+			}
+		case endOfStringToken:
+			return requirements, nil
+		default:
+			return nil, fmt.Errorf("found '%s', expected: !, identifier, or 'end of string'", literal)
+		}
+	}
+}
+
+func (p *parser) parseRequirement() (*Requirement, error) {
+	key, operator, err := p.parseKeyAndInferOperator()
+	if err != nil {
+		return nil, err
+	}
+	if operator == Exists || operator == DoesNotExist {
+		return newRequirement(key, operator, []string{}, "")
+	}
+
+	if operator, err = p.parseOperator(); err != nil {
+		return nil, err
+	}
+	var vals []string
+	if operator == In || operator == NotIn {
+		vals, err = p.parseValues()
+	} else {
+		vals, err = p.parseExactValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newRequirement(key, operator, sortedSet(vals), "")
+}
+
+// parseKeyAndInferOperator parses the key of a requirement, together with
+// the `!` operator in front of it. The operator is `Exists` when the key is
+// not followed by an operator.
+func (p *parser) parseKeyAndInferOperator() (string, Operator, error) {
+	var operator Operator
+	tok, literal := p.consume(values)
+	if tok == doesNotExistToken {
+		operator = DoesNotExist
+		tok, literal = p.consume(values)
+	}
+	if tok != identifierToken {
+		return "", "", fmt.Errorf("found '%s', expected: identifier", literal)
+	}
+	if err := validateLabelKey(literal, nilPath); err != "" {
+		return "", "", aggregate([]string{err})
+	}
+	if next, _ := p.lookahead(values); next == endOfStringToken || next == commaToken {
+		if operator != DoesNotExist {
+			operator = Exists
+		}
+	}
+	return literal, operator, nil
+}
+
+func (p *parser) parseOperator() (Operator, error) {
+	tok, literal := p.consume(keyAndOperator)
+	switch tok {
+	case inToken:
+		return In, nil
+	case equalsToken:
+		return Equals, nil
+	case doubleEqualsToken:
+		return DoubleEquals, nil
+	case greaterThanToken:
+		return GreaterThan, nil
+	case lessThanToken:
+		return LessThan, nil
+	case notInToken:
+		return NotIn, nil
+	case notEqualsToken:
+		return NotEquals, nil
+	default:
+		return "", fmt.Errorf("found '%s', expected: %v", literal, strings.Join(binaryOperators(), ", "))
+	}
+}
+
+// parseValues parses the `(value,value,...)` list of the set based
+// operators.
+func (p *parser) parseValues() ([]string, error) {
+	tok, literal := p.consume(values)
+	if tok != openParToken {
+		return nil, fmt.Errorf("found '%s' expected: '('", literal)
+	}
+
+	tok, literal = p.lookahead(values)
+	switch tok {
+	case identifierToken, commaToken:
+		vals, err := p.parseIdentifiersList()
+		if err != nil {
+			return nil, err
+		}
+		if tok, _ = p.consume(values); tok != closedParToken {
+			return nil, fmt.Errorf("found '%s', expected: ')'", literal)
+		}
+		return vals, nil
+	case closedParToken:
+		p.consume(values)
+		return []string{""}, nil
+	default:
+		return nil, fmt.Errorf("found '%s', expected: ',', ')' or identifier", literal)
+	}
+}
+
+// parseIdentifiersList parses the values separated by commas. Missing
+// values are empty strings, e.g. `(a,)` holds `a` and the empty string.
+func (p *parser) parseIdentifiersList() ([]string, error) {
+	vals := []string{}
+	for {
+		tok, literal := p.consume(values)
+		switch tok {
+		case identifierToken:
+			vals = append(vals, literal)
+			next, nextLiteral := p.lookahead(values)
+			switch next {
+			case commaToken:
+				continue
+			case closedParToken:
+				return vals, nil
+			default:
+				return nil, fmt.Errorf("found '%s', expected: ',' or ')'", nextLiteral)
+			}
+		case commaToken:
+			if len(vals) == 0 {
+				vals = append(vals, "")
+			}
+			next, _ := p.lookahead(values)
+			if next == closedParToken {
+				return append(vals, ""), nil
+			}
+			if next == commaToken {
+				p.consume(values)
+				vals = append(vals, "")
+			}
+		default:
+			return nil, fmt.Errorf("found '%s', expected: ',', or identifier", literal)
+		}
+	}
+}
+
+// parseExactValue parses the value of the `=`, `==`, `!=`, `>` and `<`
+// operators, which can be empty.
+func (p *parser) parseExactValue() ([]string, error) {
+	if tok, _ := p.lookahead(values); tok == endOfStringToken || tok == commaToken {
+		return []string{""}, nil
+	}
+	tok, literal := p.consume(values)
+	if tok == identifierToken {
+		return []string{literal}, nil
+	}
+	return nil, fmt.Errorf("found '%s', expected: identifier", literal)
+}
+
+// sortedSet returns the sorted values, without duplicates.
+func sortedSet(vals []string) []string {
+	set := map[string]bool{}
+	unique := []string{}
+	for _, value := range vals {
+		if !set[value] {
+			set[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// Parse parses the string representation of a selector, using the
+// syntax of Kubernetes:
+//   - `key`, `!key`: the label exists, or doesn't exist
+//   - `key=value`, `key==value`, `key!=value`: the label has, or doesn't
+//     have, the value
+//   - `key in (a,b)`, `key notin (a,b)`: the label has, or doesn't have,
+//     one of the values
+//   - `key>1`, `key<1`: the label holds an integer greater, or less, than
+//     the value
+//
+// The requirements are separated by commas, all of them must be satisfied.
+// The empty string matches everything.
+func Parse(selector string) (Selector, error) {
+	p := &parser{l: &lexer{s: selector}}
+	requirements, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	sortByKey(requirements)
+	return internalSelector(requirements), nil
+}
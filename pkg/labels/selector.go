@@ -0,0 +1,406 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operator is the operator of a Requirement.
+type Operator string
+
+const (
+	DoesNotExist Operator = "!"
+	Equals       Operator = "="
+	DoubleEquals Operator = "=="
+	In           Operator = "in"
+	NotEquals    Operator = "!="
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	GreaterThan  Operator = "gt"
+	LessThan     Operator = "lt"
+)
+
+// binaryOperators lists the operators requiring values, in the order used
+// by the error messages.
+func binaryOperators() []string {
+	return []string{
+		string(In), string(NotIn), string(Equals), string(DoubleEquals), string(NotEquals),
+		string(GreaterThan), string(LessThan),
+	}
+}
+
+// validRequirementOperators lists all the supported operators, in the order
+// used by the error messages.
+func validRequirementOperators() []string {
+	return append(binaryOperators(), string(Exists), string(DoesNotExist))
+}
+
+// Selector represents a label selector.
+type Selector interface {
+	// Matches returns true if this selector matches the given set of labels.
+	Matches(labels Labels) bool
+	// Empty returns true if this selector doesn't restrict the selection
+	// space.
+	Empty() bool
+	// String returns a human readable string that represents this
+	// selector, which can be parsed back by Parse.
+	String() string
+	// Add adds requirements to the selector and returns the new selector.
+	Add(requirements ...Requirement) Selector
+	// Requirements returns the requirements of this selector. The boolean
+	// is false when the selector cannot match any object.
+	Requirements() ([]Requirement, bool)
+	// RequiresExactMatch returns the value the label must have in order to
+	// match the selector, if any.
+	RequiresExactMatch(label string) (string, bool)
+}
+
+// Everything returns a selector that matches all the labels.
+func Everything() Selector {
+	return internalSelector{}
+}
+
+// Nothing returns a selector that matches no labels.
+func Nothing() Selector {
+	return nothingSelector{}
+}
+
+// NewSelector returns a selector without requirements, which matches all
+// the labels. Requirements can be added using Add.
+func NewSelector() Selector {
+	return internalSelector(nil)
+}
+
+type nothingSelector struct{}
+
+func (n nothingSelector) Matches(_ Labels) bool {
+	return false
+}
+
+func (n nothingSelector) Empty() bool {
+	return false
+}
+
+func (n nothingSelector) String() string {
+	return ""
+}
+
+func (n nothingSelector) Add(_ ...Requirement) Selector {
+	return n
+}
+
+func (n nothingSelector) Requirements() ([]Requirement, bool) {
+	return nil, false
+}
+
+func (n nothingSelector) RequiresExactMatch(_ string) (string, bool) {
+	return "", false
+}
+
+// Requirement contains a key, an operator and a set of values, which
+// together describe a condition on the labels.
+type Requirement struct {
+	key       string
+	operator  Operator
+	strValues []string
+}
+
+// NewRequirement validates and builds a requirement. The rules are the
+// ones of Kubernetes:
+//   - `In` and `NotIn` require at least one value
+//   - `Equals`, `DoubleEquals` and `NotEquals` require exactly one value
+//   - `Exists` and `DoesNotExist` require no values
+//   - `GreaterThan` and `LessThan` require exactly one value, which must be
+//     an integer
+//
+// The key and the values must be valid label keys and values.
+func NewRequirement(key string, op Operator, vals []string) (*Requirement, error) {
+	return newRequirement(key, op, vals, "")
+}
+
+func newRequirement(key string, op Operator, vals []string, path string) (*Requirement, error) {
+	var allErrs []string
+	if err := validateLabelKey(key, childPath(path, "key")); err != "" {
+		allErrs = append(allErrs, err)
+	}
+
+	valuePath := childPath(path, "values")
+	switch op {
+	case In, NotIn:
+		if len(vals) == 0 {
+			allErrs = append(allErrs, fieldError(valuePath, vals, "for 'in', 'notin' operators, values set can't be empty"))
+		}
+	case Equals, DoubleEquals, NotEquals:
+		if len(vals) != 1 {
+			allErrs = append(allErrs, fieldError(valuePath, vals, "exact-match compatibility requires one single value"))
+		}
+	case Exists, DoesNotExist:
+		if len(vals) != 0 {
+			allErrs = append(allErrs, fieldError(valuePath, vals, "values set must be empty for exists and does not exist"))
+		}
+	case GreaterThan, LessThan:
+		if len(vals) != 1 {
+			allErrs = append(allErrs, fieldError(valuePath, vals, "for 'Gt', 'Lt' operators, exactly one value is required"))
+		}
+		for i := range vals {
+			if _, err := strconv.ParseInt(vals[i], 10, 64); err != nil {
+				allErrs = append(allErrs, fieldError(indexPath(valuePath, i), vals[i],
+					"for 'Gt', 'Lt' operators, the value must be an integer"))
+			}
+		}
+	default:
+		allErrs = append(allErrs, fmt.Sprintf("%s: Unsupported value: %q: supported values: %s",
+			childPath(path, "operator"), op, quoteAll(validRequirementOperators())))
+	}
+
+	for i := range vals {
+		if err := validateLabelValue(key, vals[i], indexPath(valuePath, i)); err != "" {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return &Requirement{key: key, operator: op, strValues: vals}, aggregate(allErrs)
+}
+
+// Key returns the key of the requirement.
+func (r *Requirement) Key() string {
+	return r.key
+}
+
+// Operator returns the operator of the requirement.
+func (r *Requirement) Operator() Operator {
+	return r.operator
+}
+
+// Values returns the sorted values of the requirement.
+func (r *Requirement) Values() []string {
+	values := slices.Clone(r.strValues)
+	sort.Strings(values)
+	return slices.Compact(values)
+}
+
+// Matches tells whether the labels satisfy the requirement:
+//   - `In`, `Equals` and `DoubleEquals` require the label to exist and to
+//     have one of the values
+//   - `NotIn` and `NotEquals` require the label not to exist, or to have a
+//     value not included by the values
+//   - `Exists` and `DoesNotExist` only check the presence of the label
+//   - `GreaterThan` and `LessThan` require the label to exist and to hold
+//     an integer greater, or less, than the value
+func (r *Requirement) Matches(ls Labels) bool {
+	switch r.operator {
+	case In, Equals, DoubleEquals:
+		if !ls.Has(r.key) {
+			return false
+		}
+		return r.hasValue(ls.Get(r.key))
+	case NotIn, NotEquals:
+		if !ls.Has(r.key) {
+			return true
+		}
+		return !r.hasValue(ls.Get(r.key))
+	case Exists:
+		return ls.Has(r.key)
+	case DoesNotExist:
+		return !ls.Has(r.key)
+	case GreaterThan, LessThan:
+		return r.compare(ls)
+	default:
+		return false
+	}
+}
+
+func (r *Requirement) compare(ls Labels) bool {
+	if !ls.Has(r.key) || len(r.strValues) != 1 {
+		return false
+	}
+	labelValue, err := strconv.ParseInt(ls.Get(r.key), 10, 64)
+	if err != nil {
+		return false
+	}
+	requiredValue, err := strconv.ParseInt(r.strValues[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	return (r.operator == GreaterThan && labelValue > requiredValue) ||
+		(r.operator == LessThan && labelValue < requiredValue)
+}
+
+func (r *Requirement) hasValue(value string) bool {
+	return slices.Contains(r.strValues, value)
+}
+
+// String returns the requirement using the syntax accepted by Parse.
+func (r *Requirement) String() string {
+	builder := strings.Builder{}
+	if r.operator == DoesNotExist {
+		builder.WriteString("!")
+	}
+	builder.WriteString(r.key)
+
+	switch r.operator {
+	case Equals:
+		builder.WriteString("=")
+	case DoubleEquals:
+		builder.WriteString("==")
+	case NotEquals:
+		builder.WriteString("!=")
+	case In:
+		builder.WriteString(" in ")
+	case NotIn:
+		builder.WriteString(" notin ")
+	case GreaterThan:
+		builder.WriteString(">")
+	case LessThan:
+		builder.WriteString("<")
+	case Exists, DoesNotExist:
+		return builder.String()
+	}
+
+	isSet := r.operator == In || r.operator == NotIn
+	if isSet {
+		builder.WriteString("(")
+	}
+	if len(r.strValues) == 1 {
+		builder.WriteString(r.strValues[0])
+	} else {
+		values := slices.Clone(r.strValues)
+		sort.Strings(values)
+		builder.WriteString(strings.Join(values, ","))
+	}
+	if isSet {
+		builder.WriteString(")")
+	}
+	return builder.String()
+}
+
+// internalSelector is a list of requirements, all of them must be
+// satisfied. It's kept sorted by key.
+type internalSelector []Requirement
+
+func (s internalSelector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s internalSelector) Add(requirements ...Requirement) Selector {
+	selector := make(internalSelector, 0, len(s)+len(requirements))
+	selector = append(selector, s...)
+	selector = append(selector, requirements...)
+	sortByKey(selector)
+	return selector
+}
+
+func (s internalSelector) Matches(ls Labels) bool {
+	for i := range s {
+		if !s[i].Matches(ls) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s internalSelector) Requirements() ([]Requirement, bool) {
+	return s, true
+}
+
+func (s internalSelector) String() string {
+	requirements := make([]string, 0, len(s))
+	for i := range s {
+		requirements = append(requirements, s[i].String())
+	}
+	return strings.Join(requirements, ",")
+}
+
+func (s internalSelector) RequiresExactMatch(label string) (string, bool) {
+	for i := range s {
+		if s[i].key != label {
+			continue
+		}
+		switch s[i].operator {
+		case Equals, DoubleEquals, In:
+			if len(s[i].strValues) == 1 {
+				return s[i].strValues[0], true
+			}
+		case NotIn, NotEquals, Exists, DoesNotExist, GreaterThan, LessThan:
+		}
+		return "", false
+	}
+	return "", false
+}
+
+func sortByKey(requirements []Requirement) {
+	sort.SliceStable(requirements, func(i, j int) bool {
+		return requirements[i].key < requirements[j].key
+	})
+}
+
+// SelectorFromSet returns a selector matching the objects having all the
+// labels of the set. The labels are not validated: invalid labels produce
+// a selector that doesn't match any object, see ValidatedSelectorFromSet.
+func SelectorFromSet(ls Set) Selector {
+	if len(ls) == 0 {
+		return internalSelector{}
+	}
+	requirements := make([]Requirement, 0, len(ls))
+	for label, value := range ls {
+		requirements = append(requirements, Requirement{key: label, operator: Equals, strValues: []string{value}})
+	}
+	sortByKey(requirements)
+	return internalSelector(requirements)
+}
+
+// ValidatedSelectorFromSet is like SelectorFromSet, but it returns an error
+// when the set contains invalid label keys or values.
+func ValidatedSelectorFromSet(ls Set) (Selector, error) {
+	if len(ls) == 0 {
+		return internalSelector{}, nil
+	}
+	requirements := make([]Requirement, 0, len(ls))
+	for label, value := range ls {
+		requirement, err := NewRequirement(label, Equals, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, *requirement)
+	}
+	sortByKey(requirements)
+	return internalSelector(requirements), nil
+}
+
+// childPath and indexPath build the paths of the fields reported by the
+// validation errors, like `values[0]`. The empty path is the root.
+func childPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+// aggregate joins the validation errors using the format of Kubernetes:
+// a single error is returned as is, multiple ones are listed inside of
+// brackets.
+func aggregate(errs []string) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(errs[0])
+	default:
+		return errors.New("[" + strings.Join(errs, ", ") + "]")
+	}
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,145 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The validation rules of the label keys and values, as defined by
+// Kubernetes apimachinery.
+const (
+	qnameCharFmt           = "[A-Za-z0-9]"
+	qnameExtCharFmt        = "[-A-Za-z0-9_.]"
+	qualifiedNameFmt       = "(" + qnameCharFmt + qnameExtCharFmt + "*)?" + qnameCharFmt
+	qualifiedNameErrMsg    = "must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	qualifiedNameMaxLength = 63
+
+	labelValueFmt       = "(" + qualifiedNameFmt + ")?"
+	labelValueErrMsg    = "a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character"
+	labelValueMaxLength = 63
+
+	dns1123LabelFmt           = "[a-z0-9]([-a-z0-9]*[a-z0-9])?"
+	dns1123SubdomainFmt       = dns1123LabelFmt + "(\\." + dns1123LabelFmt + ")*"
+	dns1123SubdomainErrorMsg  = "a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"
+	dns1123SubdomainMaxLength = 253
+)
+
+//nolint:gochecknoglobals // compiled once, the expressions are constant
+var (
+	qualifiedNameRegexp    = regexp.MustCompile("^" + qualifiedNameFmt + "$")
+	labelValueRegexp       = regexp.MustCompile("^" + labelValueFmt + "$")
+	dns1123SubdomainRegexp = regexp.MustCompile("^" + dns1123SubdomainFmt + "$")
+)
+
+// IsQualifiedName tests whether the value is a valid label key: a name
+// with an optional DNS subdomain prefix, e.g. `app.kubernetes.io/name`.
+// It returns the list of the problems found, which is empty for valid keys.
+func IsQualifiedName(value string) []string {
+	var errs []string
+	parts := strings.Split(value, "/")
+	var name string
+	switch len(parts) {
+	case 1:
+		name = parts[0]
+	case 2: //nolint:mnd // prefix and name
+		var prefix string
+		prefix, name = parts[0], parts[1]
+		if len(prefix) == 0 {
+			errs = append(errs, "prefix part "+emptyError())
+		} else if msgs := isDNS1123Subdomain(prefix); len(msgs) != 0 {
+			errs = append(errs, prefixEach(msgs, "prefix part ")...)
+		}
+	default:
+		return append(errs, "a qualified name "+regexError(qualifiedNameErrMsg, qualifiedNameFmt, "MyName", "my.name", "123-abc")+
+			" with an optional DNS subdomain prefix and '/' (e.g. 'example.com/MyName')")
+	}
+
+	if len(name) == 0 {
+		errs = append(errs, "name part "+emptyError())
+	} else if len(name) > qualifiedNameMaxLength {
+		errs = append(errs, "name part "+maxLenError(qualifiedNameMaxLength))
+	}
+	if !qualifiedNameRegexp.MatchString(name) {
+		errs = append(errs, "name part "+regexError(qualifiedNameErrMsg, qualifiedNameFmt, "MyName", "my.name", "123-abc"))
+	}
+	return errs
+}
+
+// IsValidLabelValue tests whether the value is a valid label value. It
+// returns the list of the problems found, which is empty for valid values.
+func IsValidLabelValue(value string) []string {
+	var errs []string
+	if len(value) > labelValueMaxLength {
+		errs = append(errs, maxLenError(labelValueMaxLength))
+	}
+	if !labelValueRegexp.MatchString(value) {
+		errs = append(errs, regexError(labelValueErrMsg, labelValueFmt, "MyValue", "my_value", "12345"))
+	}
+	return errs
+}
+
+func isDNS1123Subdomain(value string) []string {
+	var errs []string
+	if len(value) > dns1123SubdomainMaxLength {
+		errs = append(errs, maxLenError(dns1123SubdomainMaxLength))
+	}
+	if !dns1123SubdomainRegexp.MatchString(value) {
+		errs = append(errs, regexError(dns1123SubdomainErrorMsg, dns1123SubdomainFmt, "example.com"))
+	}
+	return errs
+}
+
+func emptyError() string {
+	return "must be non-empty"
+}
+
+func maxLenError(length int) string {
+	return fmt.Sprintf("must be no more than %d characters", length)
+}
+
+func regexError(msg, format string, examples ...string) string {
+	if len(examples) == 0 {
+		return msg + " (regex used for validation is '" + format + "')"
+	}
+	msg += " (e.g. "
+	for i := range examples {
+		if i > 0 {
+			msg += " or "
+		}
+		msg += "'" + examples[i] + "', "
+	}
+	msg += "regex used for validation is '" + format + "')"
+	return msg
+}
+
+func prefixEach(msgs []string, prefix string) []string {
+	for i := range msgs {
+		msgs[i] = prefix + msgs[i]
+	}
+	return msgs
+}
+
+// fieldError builds the message of an invalid value, using the format of
+// the errors of the Kubernetes API server: `<path>: Invalid value: <value>:
+// <detail>`.
+func fieldError(path string, value interface{}, detail string) string {
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%s: Invalid value: %q: %s", path, text, detail)
+	}
+	return fmt.Sprintf("%s: Invalid value: %#v: %s", path, value, detail)
+}
+
+func validateLabelKey(key, path string) string {
+	if errs := IsQualifiedName(key); len(errs) != 0 {
+		return fieldError(path, key, strings.Join(errs, "; "))
+	}
+	return ""
+}
+
+func validateLabelValue(key, value, path string) string {
+	if errs := IsValidLabelValue(value); len(errs) != 0 {
+		return fieldError(path+"["+key+"]", value, strings.Join(errs, "; "))
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+// This package matches the users making the requests against lists of
+// principals, usually provided by the settings of the policies. It allows
+// the policies to implement exemption lists in a consistent way:
+//
+//	type Settings struct {
+//		Exemptions principal.Matcher `json:"exemptions"`
+//	}
+//
+//	if settings.Exemptions.Matches(validationRequest.Request.UserInfo) {
+//		return kubewarden.AcceptRequest()
+//	}
+package principal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/internal/duplicates"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// Matcher matches the users by name or by group membership.
+//
+// The zero value doesn't match any user.
+type Matcher struct {
+	// Users holds the names of the users. The service accounts of a whole
+	// namespace can be matched using the
+	// `system:serviceaccount:<namespace>:*` pattern
+	Users []string `json:"users,omitempty"`
+	// Groups holds the names of the groups, the user must belong to at
+	// least one of them
+	Groups []string `json:"groups,omitempty"`
+}
+
+// IsEmpty tells whether the matcher has neither users nor groups.
+func (m Matcher) IsEmpty() bool {
+	return len(m.Users) == 0 && len(m.Groups) == 0
+}
+
+// Matches tells whether the user is one of the users of the matcher, or
+// belongs to one of its groups.
+func (m Matcher) Matches(userInfo protocol.UserInfo) bool {
+	for _, user := range m.Users {
+		if matchesUser(user, userInfo.Username) {
+			return true
+		}
+	}
+	for _, group := range m.Groups {
+		if userInfo.InGroup(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the users and the groups of the matcher. All the problems
+// are reported, joined with `errors.Join`, so that the matcher can be
+// validated as part of the settings of a policy.
+func (m Matcher) Validate() error {
+	errs := []error{}
+	for _, user := range m.Users {
+		if err := validateUser(user); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, group := range m.Groups {
+		if strings.TrimSpace(group) == "" {
+			errs = append(errs, errors.New("group names cannot be empty"))
+		}
+	}
+	if duplicate, found := duplicates.Find(m.Users); found {
+		errs = append(errs, fmt.Errorf("user %q is listed more than once", duplicate))
+	}
+	if duplicate, found := duplicates.Find(m.Groups); found {
+		errs = append(errs, fmt.Errorf("group %q is listed more than once", duplicate))
+	}
+	return errors.Join(errs...)
+}
+
+// matchesUser tells whether the username matches the user of a Matcher,
+// which can be a service account pattern.
+func matchesUser(user, username string) bool {
+	if namespace, isPattern := serviceAccountPattern(user); isPattern {
+		usernameNamespace, _, isServiceAccount := protocol.ParseServiceAccountUsername(username)
+		return isServiceAccount && usernameNamespace == namespace
+	}
+	return user == username
+}
+
+// serviceAccountPattern returns the namespace of a
+// `system:serviceaccount:<namespace>:*` pattern.
+func serviceAccountPattern(user string) (string, bool) {
+	namespace, name, isServiceAccount := protocol.ParseServiceAccountUsername(user)
+	return namespace, isServiceAccount && name == "*"
+}
+
+func validateUser(user string) error {
+	switch {
+	case strings.TrimSpace(user) == "":
+		return errors.New("user names cannot be empty")
+	case !strings.Contains(user, "*"):
+		return nil
+	}
+
+	namespace, isPattern := serviceAccountPattern(user)
+	if !isPattern || strings.Contains(namespace, "*") {
+		return fmt.Errorf("invalid user %q: wildcards are allowed only as `system:serviceaccount:<namespace>:*`", user)
+	}
+	return nil
+}
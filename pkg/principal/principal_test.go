@@ -0,0 +1,113 @@
+package principal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+func TestMatches(t *testing.T) {
+	matcher := Matcher{
+		Users:  []string{"alice", "system:serviceaccount:kube-system:*"},
+		Groups: []string{"system:masters"},
+	}
+
+	for description, testCase := range map[string]struct {
+		userInfo protocol.UserInfo
+		expected bool
+	}{
+		"ExactUser": {
+			userInfo: protocol.UserInfo{Username: "alice"},
+			expected: true,
+		},
+		"OtherUser": {
+			userInfo: protocol.UserInfo{Username: "bob", Groups: []string{"system:authenticated"}},
+		},
+		"Group": {
+			userInfo: protocol.UserInfo{Username: "bob", Groups: []string{"system:authenticated", "system:masters"}},
+			expected: true,
+		},
+		"ServiceAccountOfNamespace": {
+			userInfo: protocol.UserInfo{Username: "system:serviceaccount:kube-system:replicaset-controller"},
+			expected: true,
+		},
+		"ServiceAccountOfOtherNamespace": {
+			userInfo: protocol.UserInfo{Username: "system:serviceaccount:default:replicaset-controller"},
+		},
+		"NamespacePrefix": {
+			userInfo: protocol.UserInfo{Username: "system:serviceaccount:kube-system-other:default"},
+		},
+		"LiteralPattern": {
+			userInfo: protocol.UserInfo{Username: "system:serviceaccount:kube-system:*"},
+			expected: true,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			if matches := matcher.Matches(testCase.userInfo); matches != testCase.expected {
+				t.Fatalf("expected %t, got %t", testCase.expected, matches)
+			}
+		})
+	}
+
+	if (Matcher{}).Matches(protocol.UserInfo{Username: "alice"}) {
+		t.Fatalf("the empty matcher shouldn't match any user")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		matcher       Matcher
+		expectedError string
+	}{
+		"Valid": {
+			matcher: Matcher{
+				Users:  []string{"alice", "system:serviceaccount:kube-system:*"},
+				Groups: []string{"system:masters"},
+			},
+		},
+		"Empty": {
+			matcher: Matcher{},
+		},
+		"InvalidWildcards": {
+			matcher: Matcher{Users: []string{"admin-*", "system:serviceaccount:*:default"}},
+			expectedError: "invalid user \"admin-*\": wildcards are allowed only as `system:serviceaccount:<namespace>:*`\n" +
+				"invalid user \"system:serviceaccount:*:default\": " +
+				"wildcards are allowed only as `system:serviceaccount:<namespace>:*`",
+		},
+		"EmptyNames": {
+			matcher:       Matcher{Users: []string{""}, Groups: []string{" "}},
+			expectedError: "user names cannot be empty\ngroup names cannot be empty",
+		},
+		"Duplicates": {
+			matcher:       Matcher{Users: []string{"alice", "bob", "alice"}, Groups: []string{"a", "a"}},
+			expectedError: "user \"alice\" is listed more than once\ngroup \"a\" is listed more than once",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			err := testCase.matcher.Validate()
+			if testCase.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var settings struct {
+		Exemptions Matcher `json:"exemptions"`
+	}
+	payload := `{"exemptions": {"users": ["alice"], "groups": ["system:masters"]}}`
+	if err := json.Unmarshal([]byte(payload), &settings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !settings.Exemptions.Matches(protocol.UserInfo{Username: "bob", Groups: []string{"system:masters"}}) {
+		t.Fatalf("the decoded matcher should match the group")
+	}
+}
@@ -0,0 +1,174 @@
+package quantity
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// sidecarRestartPolicy is the restart policy of the init containers that
+// keep running together with the regular containers.
+const sidecarRestartPolicy = "Always"
+
+// ResourceList maps the name of the resources, like `cpu` or `memory`, to
+// their quantity.
+type ResourceList map[string]Quantity
+
+// ParseResourceList parses the quantities of a k8s-objects resource list,
+// like the limits or the requests of a container. Nil quantities are
+// skipped.
+func ParseResourceList(list map[string]*resource.Quantity) (ResourceList, error) {
+	parsed := ResourceList{}
+	for _, name := range sortedNames(list) {
+		if list[name] == nil {
+			continue
+		}
+		q, err := ParseResource(*list[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for resource %s: %w", *list[name], name, err)
+		}
+		parsed[name] = q
+	}
+	return parsed, nil
+}
+
+// ToResourceList returns the list as a k8s-objects resource list, using the
+// canonical form of the quantities.
+func (list ResourceList) ToResourceList() map[string]*resource.Quantity {
+	converted := make(map[string]*resource.Quantity, len(list))
+	for name, q := range list {
+		converted[name] = q.ToResource()
+	}
+	return converted
+}
+
+// Get returns the quantity of the resource, which is zero when the resource
+// is not listed.
+func (list ResourceList) Get(name string) Quantity {
+	return list[name]
+}
+
+// add adds the quantities of other to the list.
+func (list ResourceList) add(other ResourceList) {
+	for name, q := range other {
+		sum := list[name]
+		sum.Add(q)
+		list[name] = sum
+	}
+}
+
+// raiseTo replaces the quantities of the list with the ones of other, when
+// they are greater.
+func (list ResourceList) raiseTo(other ResourceList) {
+	for name, q := range other {
+		current, found := list[name]
+		if !found || q.Cmp(current) > 0 {
+			list[name] = q
+		}
+	}
+}
+
+// PodRequests returns the resources requested by the pod, which are the
+// ones used by the scheduler:
+//   - the sum of the requests of the containers, including the sidecars,
+//     i.e. the init containers whose restart policy is `Always`
+//   - or, when greater, the requests of each init container, together with
+//     the sidecars started before it
+//   - plus the overhead of the pod
+//
+// Containers without requests don't contribute to the sum: the requests
+// default to the limits when the pod is created, before the policies are
+// evaluated.
+func PodRequests(podSpec corev1.PodSpec) (ResourceList, error) {
+	return podResources(podSpec, func(resources *corev1.ResourceRequirements) map[string]*resource.Quantity {
+		return resources.Requests
+	}, false)
+}
+
+// PodLimits returns the limits of the pod, computed like PodRequests. The
+// overhead of the pod is added only to the resources limited by the
+// containers. A resource that isn't limited by all the containers is
+// effectively unlimited, while the returned list contains the sum of the
+// existing limits: policies requiring limits must check each container.
+func PodLimits(podSpec corev1.PodSpec) (ResourceList, error) {
+	return podResources(podSpec, func(resources *corev1.ResourceRequirements) map[string]*resource.Quantity {
+		return resources.Limits
+	}, true)
+}
+
+func podResources(
+	podSpec corev1.PodSpec,
+	selectList func(*corev1.ResourceRequirements) map[string]*resource.Quantity,
+	overheadOfListedOnly bool,
+) (ResourceList, error) {
+	containerResources := func(container *corev1.Container) (ResourceList, error) {
+		if container == nil || container.Resources == nil {
+			return ResourceList{}, nil
+		}
+		list, err := ParseResourceList(selectList(container.Resources))
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", containerName(container), err)
+		}
+		return list, nil
+	}
+
+	total := ResourceList{}
+	for _, container := range podSpec.Containers {
+		list, err := containerResources(container)
+		if err != nil {
+			return nil, err
+		}
+		total.add(list)
+	}
+
+	sidecars := ResourceList{}
+	initContainers := ResourceList{}
+	for _, container := range podSpec.InitContainers {
+		list, err := containerResources(container)
+		if err != nil {
+			return nil, fmt.Errorf("init %w", err)
+		}
+
+		if container != nil && container.RestartPolicy == sidecarRestartPolicy {
+			// sidecars keep running until the pod terminates
+			total.add(list)
+			sidecars.add(list)
+			initContainers.raiseTo(sidecars)
+			continue
+		}
+		// the sidecars started before the init container run together with it
+		list.add(sidecars)
+		initContainers.raiseTo(list)
+	}
+	total.raiseTo(initContainers)
+
+	overhead, err := ParseResourceList(podSpec.Overhead)
+	if err != nil {
+		return nil, fmt.Errorf("pod overhead: %w", err)
+	}
+	for name := range overhead {
+		if _, found := total[name]; !found && overheadOfListedOnly {
+			delete(overhead, name)
+		}
+	}
+	total.add(overhead)
+	return total, nil
+}
+
+func containerName(container *corev1.Container) string {
+	if container.Name == nil {
+		return "<unnamed>"
+	}
+	return *container.Name
+}
+
+func sortedNames(list map[string]*resource.Quantity) []string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
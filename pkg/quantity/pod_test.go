@@ -0,0 +1,174 @@
+package quantity
+
+import (
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func newContainer(name, restartPolicy string, requests map[string]string) *corev1.Container {
+	list := map[string]*resource.Quantity{}
+	for resourceName, value := range requests {
+		q := resource.Quantity(value)
+		list[resourceName] = &q
+	}
+	return &corev1.Container{
+		Name:          &name,
+		RestartPolicy: restartPolicy,
+		Resources:     &corev1.ResourceRequirements{Requests: list, Limits: list},
+	}
+}
+
+func formatList(list ResourceList) map[string]string {
+	formatted := map[string]string{}
+	for name, q := range list {
+		formatted[name] = q.String()
+	}
+	return formatted
+}
+
+func TestPodRequests(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		podSpec  corev1.PodSpec
+		expected map[string]string
+	}{
+		"Empty": {
+			podSpec:  corev1.PodSpec{},
+			expected: map[string]string{},
+		},
+		"SumOfContainers": {
+			podSpec: corev1.PodSpec{
+				Containers: []*corev1.Container{
+					newContainer("app", "", map[string]string{"cpu": "500m", "memory": "1Gi"}),
+					newContainer("proxy", "", map[string]string{"cpu": "0.1", "memory": "128Mi"}),
+					{Name: nil},
+				},
+			},
+			expected: map[string]string{"cpu": "600m", "memory": "1152Mi"},
+		},
+		"InitContainerMax": {
+			podSpec: corev1.PodSpec{
+				Containers: []*corev1.Container{
+					newContainer("app", "", map[string]string{"cpu": "500m", "memory": "1Gi"}),
+				},
+				InitContainers: []*corev1.Container{
+					newContainer("migrate", "", map[string]string{"cpu": "2", "memory": "256Mi"}),
+					newContainer("setup", "", map[string]string{"cpu": "1", "memory": "2Gi"}),
+				},
+			},
+			expected: map[string]string{"cpu": "2", "memory": "2Gi"},
+		},
+		"Sidecars": {
+			podSpec: corev1.PodSpec{
+				Containers: []*corev1.Container{
+					newContainer("app", "", map[string]string{"cpu": "1"}),
+				},
+				InitContainers: []*corev1.Container{
+					newContainer("mesh", "Always", map[string]string{"cpu": "500m"}),
+					newContainer("migrate", "", map[string]string{"cpu": "1200m"}),
+					newContainer("logs", "Always", map[string]string{"cpu": "200m"}),
+				},
+			},
+			// migrate runs together with mesh: 1.7 CPUs, while app runs
+			// together with both the sidecars: 1.7 CPUs
+			expected: map[string]string{"cpu": "1700m"},
+		},
+		"SidecarsExceedingInitContainers": {
+			podSpec: corev1.PodSpec{
+				InitContainers: []*corev1.Container{
+					newContainer("mesh", "Always", map[string]string{"cpu": "500m"}),
+					newContainer("migrate", "", map[string]string{"cpu": "100m"}),
+				},
+			},
+			expected: map[string]string{"cpu": "600m"},
+		},
+		"Overhead": {
+			podSpec: corev1.PodSpec{
+				Containers: []*corev1.Container{
+					newContainer("app", "", map[string]string{"cpu": "1", "memory": "1Gi"}),
+				},
+				Overhead: newContainer("", "", map[string]string{"cpu": "250m", "memory": "120Mi"}).Resources.Requests,
+			},
+			expected: map[string]string{"cpu": "1250m", "memory": "1144Mi"},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			requests, err := PodRequests(testCase.podSpec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			formatted := formatList(requests)
+			if len(formatted) != len(testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, formatted)
+			}
+			for name, value := range testCase.expected {
+				if formatted[name] != value {
+					t.Fatalf("expected %v, got %v", testCase.expected, formatted)
+				}
+			}
+
+			limits, err := PodLimits(testCase.podSpec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(limits) != len(requests) {
+				t.Fatalf("the limits should be equal to the requests, got %v", formatList(limits))
+			}
+		})
+	}
+}
+
+func TestPodLimitsOverhead(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		Containers: []*corev1.Container{
+			newContainer("app", "", map[string]string{"cpu": "1"}),
+		},
+		Overhead: newContainer("", "", map[string]string{"cpu": "250m", "memory": "120Mi"}).Resources.Requests,
+	}
+
+	limits, err := PodLimits(podSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if formatted := formatList(limits); len(formatted) != 1 || formatted["cpu"] != "1250m" {
+		t.Fatalf("the overhead should be added only to the limited resources, got %v", formatted)
+	}
+
+	requests, err := PodRequests(podSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if formatted := formatList(requests); len(formatted) != 2 || formatted["memory"] != "120Mi" {
+		t.Fatalf("the overhead should be added to all the requests, got %v", formatted)
+	}
+}
+
+func TestPodRequestsErrors(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		InitContainers: []*corev1.Container{
+			newContainer("setup", "", map[string]string{"cpu": "1", "memory": "1GB"}),
+		},
+	}
+	_, err := PodRequests(podSpec)
+	expectedError := `init container setup: invalid quantity "1GB" for resource memory: unable to parse quantity's suffix`
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResourceList(t *testing.T) {
+	list, err := ParseResourceList(newContainer("app", "", map[string]string{"cpu": "1000m"}).Resources.Limits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu := list.Get("cpu"); cpu.MilliValue() != 1000 {
+		t.Fatalf("unexpected cpu: %s", cpu.String())
+	}
+	if memory := list.Get("memory"); !memory.IsZero() {
+		t.Fatalf("unexpected memory: %s", memory.String())
+	}
+	if converted := list.ToResourceList(); *converted["cpu"] != "1" {
+		t.Fatalf("unexpected conversion: %s", *converted["cpu"])
+	}
+}
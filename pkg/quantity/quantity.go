@@ -0,0 +1,470 @@
+// This package parses, compares and formats Kubernetes resource quantities,
+// like `500m` CPUs or `1Gi` of memory, with the same semantics of the
+// `resource` package of Kubernetes apimachinery.
+//
+// The `resource.Quantity` type of k8s-objects is a plain string, quantities
+// must be parsed before they can be compared or summed:
+//
+//	limit, err := quantity.Parse(string(*container.Resources.Limits["memory"]))
+//	if limit.Cmp(quantity.MustParse("1Gi")) > 0 {
+//		// the container can use more than 1Gi of memory
+//	}
+//
+// Values are stored with a precision of 10^-9, smaller values are rounded
+// up, away from zero, like Kubernetes does.
+package quantity
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// Format is the format used to serialize a Quantity.
+type Format string
+
+const (
+	// DecimalExponent is used by quantities like `12e6`.
+	DecimalExponent Format = "DecimalExponent"
+	// BinarySI is used by quantities like `12Mi`.
+	BinarySI Format = "BinarySI"
+	// DecimalSI is used by quantities like `12M`.
+	DecimalSI Format = "DecimalSI"
+)
+
+var (
+	// ErrFormatWrong is returned when the quantity doesn't have the
+	// `<number><suffix>` form.
+	ErrFormatWrong = errors.New("quantities must match the regular expression " +
+		"'^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+	// ErrSuffix is returned when the suffix of the quantity is unknown.
+	ErrSuffix = errors.New("unable to parse quantity's suffix")
+	// ErrExponentRange is returned when the decimal exponent is larger
+	// than the exponent of the `E` suffix.
+	ErrExponentRange = errors.New("quantity's exponent is out of range")
+)
+
+const (
+	// nanoDigits is the number of decimal digits kept by the quantities.
+	nanoDigits = 9
+	// decimalStep is the exponent step of the decimal SI suffixes.
+	decimalStep = 3
+	// binaryStep is the exponent step, in bits, of the binary SI suffixes.
+	binaryStep = 10
+	// maxBinaryExponent is the exponent of the largest binary SI suffix,
+	// `Ei`.
+	maxBinaryExponent = 60
+	// maxDecimalExponent is the exponent of the largest decimal SI suffix,
+	// `E`, which is also the largest power of 10 held by an int64.
+	maxDecimalExponent = 18
+	// decimalBase and binaryBase are the bases of the suffixes.
+	decimalBase = 10
+	binaryBase  = 1024
+)
+
+// Quantity is a fixed-point number, with a precision of 10^-9.
+//
+// The zero value is 0, using the DecimalSI format.
+type Quantity struct {
+	// nanos holds the value of the quantity, multiplied by 10^9
+	nanos *big.Int
+	// Format is the format used to serialize the quantity, it's the format
+	// of the parsed string
+	Format Format
+}
+
+// Parse parses a quantity in any of the forms accepted by Kubernetes: a
+// signed decimal number followed by a binary SI suffix (`Ki`, `Mi`, `Gi`,
+// `Ti`, `Pi`, `Ei`), a decimal SI suffix (`n`, `u`, `m`, `k`, `M`, `G`, `T`,
+// `P`, `E`) or a decimal exponent (`e3`, `E-6`).
+func Parse(str string) (Quantity, error) {
+	if str == "" {
+		return Quantity{}, ErrFormatWrong
+	}
+	if str == "0" {
+		return Quantity{Format: DecimalSI}, nil
+	}
+
+	positive, number, suffix, err := splitQuantity(str)
+	if err != nil {
+		return Quantity{}, err
+	}
+	base, exponent, format, err := parseSuffix(suffix)
+	if err != nil {
+		return Quantity{}, err
+	}
+
+	whole, fraction, _ := strings.Cut(number, ".")
+	mantissa, ok := new(big.Int).SetString(whole+fraction, decimalBase)
+	if !ok {
+		return Quantity{}, ErrFormatWrong
+	}
+
+	if exponent < -(len(whole) + nanoDigits) {
+		// the value is smaller than 10^-9 and it's rounded up anyway, a
+		// smaller exponent produces the same result
+		exponent = -(len(whole) + nanoDigits + 1)
+	}
+
+	// value = mantissa / 10^len(fraction) * base^exponent, scaled by 10^9
+	numerator := new(big.Int).Mul(mantissa, pow(decimalBase, nanoDigits))
+	denominator := pow(decimalBase, len(fraction))
+	multiplier := pow(base, abs(exponent))
+	if exponent >= 0 {
+		numerator.Mul(numerator, multiplier)
+	} else {
+		denominator.Mul(denominator, multiplier)
+	}
+	nanos := divideRoundingUp(numerator, denominator)
+
+	if format == BinarySI && nanos.Cmp(pow(decimalBase, nanoDigits)) < 0 && nanos.Sign() > 0 {
+		// values less than 1 cannot be represented using binary suffixes
+		format = DecimalSI
+	}
+	if !positive {
+		nanos.Neg(nanos)
+	}
+	return Quantity{nanos: nanos, Format: format}, nil
+}
+
+// MustParse is like Parse, but panics when the quantity is not valid. It's
+// meant to be used with constant quantities.
+func MustParse(str string) Quantity {
+	q, err := Parse(str)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// ParseResource parses a quantity of a k8s-objects type.
+func ParseResource(q resource.Quantity) (Quantity, error) {
+	return Parse(string(q))
+}
+
+// NewQuantity returns a quantity holding the integer value.
+func NewQuantity(value int64, format Format) Quantity {
+	nanos := new(big.Int).Mul(big.NewInt(value), pow(decimalBase, nanoDigits))
+	return Quantity{nanos: nanos, Format: format}
+}
+
+// NewMilliQuantity returns a quantity holding value * 10^-3.
+func NewMilliQuantity(value int64, format Format) Quantity {
+	nanos := new(big.Int).Mul(big.NewInt(value), pow(decimalBase, nanoDigits-decimalStep))
+	return Quantity{nanos: nanos, Format: format}
+}
+
+// splitQuantity splits the quantity into its sign, its number and its
+// suffix.
+func splitQuantity(str string) (bool, string, string, error) {
+	positive := true
+	pos := 0
+	switch str[0] {
+	case '-':
+		positive = false
+		pos++
+	case '+':
+		pos++
+	}
+
+	start := pos
+	digits, dots := 0, 0
+	for ; pos < len(str); pos++ {
+		switch {
+		case str[pos] >= '0' && str[pos] <= '9':
+			digits++
+		case str[pos] == '.':
+			dots++
+		default:
+			return positive, str[start:pos], str[pos:], checkNumber(digits, dots)
+		}
+	}
+	return positive, str[start:], "", checkNumber(digits, dots)
+}
+
+func checkNumber(digits, dots int) error {
+	if digits == 0 || dots > 1 {
+		return ErrFormatWrong
+	}
+	return nil
+}
+
+// suffix describes one of the suffixes of the quantities, which multiplies
+// the number by base^exponent.
+type suffix struct {
+	symbol   string
+	base     int
+	exponent int
+	format   Format
+}
+
+func suffixes() []suffix {
+	return []suffix{
+		{symbol: "n", base: decimalBase, exponent: -9, format: DecimalSI},
+		{symbol: "u", base: decimalBase, exponent: -6, format: DecimalSI},
+		{symbol: "m", base: decimalBase, exponent: -3, format: DecimalSI},
+		{symbol: "", base: decimalBase, exponent: 0, format: DecimalSI},
+		{symbol: "k", base: decimalBase, exponent: 3, format: DecimalSI},
+		{symbol: "M", base: decimalBase, exponent: 6, format: DecimalSI},
+		{symbol: "G", base: decimalBase, exponent: 9, format: DecimalSI},
+		{symbol: "T", base: decimalBase, exponent: 12, format: DecimalSI},
+		{symbol: "P", base: decimalBase, exponent: 15, format: DecimalSI},
+		{symbol: "E", base: decimalBase, exponent: maxDecimalExponent, format: DecimalSI},
+		{symbol: "Ki", base: 2, exponent: 10, format: BinarySI},
+		{symbol: "Mi", base: 2, exponent: 20, format: BinarySI},
+		{symbol: "Gi", base: 2, exponent: 30, format: BinarySI},
+		{symbol: "Ti", base: 2, exponent: 40, format: BinarySI},
+		{symbol: "Pi", base: 2, exponent: 50, format: BinarySI},
+		{symbol: "Ei", base: 2, exponent: maxBinaryExponent, format: BinarySI},
+	}
+}
+
+// parseSuffix returns the base and the exponent described by the suffix,
+// together with the format it belongs to.
+func parseSuffix(symbol string) (int, int, Format, error) {
+	for _, known := range suffixes() {
+		if known.symbol == symbol {
+			return known.base, known.exponent, known.format, nil
+		}
+	}
+
+	if symbol[0] != 'e' && symbol[0] != 'E' {
+		return 0, 0, "", ErrSuffix
+	}
+	exponent, err := strconv.ParseInt(symbol[1:], decimalBase, 32)
+	if err != nil {
+		return 0, 0, "", ErrSuffix
+	}
+	if exponent > maxDecimalExponent {
+		return 0, 0, "", ErrExponentRange
+	}
+	return decimalBase, int(exponent), DecimalExponent, nil
+}
+
+// formatSuffix returns the suffix of the format matching the exponent,
+// falling back to the decimal exponent notation.
+func formatSuffix(format Format, exponent int) string {
+	for _, known := range suffixes() {
+		if known.format == format && known.exponent == exponent {
+			return known.symbol
+		}
+	}
+	if exponent == 0 {
+		return ""
+	}
+	return "e" + strconv.Itoa(exponent)
+}
+
+// String returns the canonical form of the quantity, which uses the
+// largest suffix of its format that doesn't need decimal digits, e.g. `1k`
+// instead of `1000` and `1536Mi` instead of `1.5Gi`. Binary quantities that
+// cannot be represented exactly with a binary suffix use the DecimalSI
+// format.
+func (q *Quantity) String() string {
+	if q.IsZero() {
+		return "0"
+	}
+
+	format := q.Format
+	if format == "" || (format == BinarySI && !q.canUseBinarySuffix()) {
+		format = DecimalSI
+	}
+
+	if format == BinarySI {
+		mantissa := q.integerValue()
+		exponent := 0
+		unit := big.NewInt(binaryBase)
+		remainder := new(big.Int)
+		for exponent < maxBinaryExponent {
+			quotient, rest := new(big.Int).QuoRem(mantissa, unit, remainder)
+			if rest.Sign() != 0 {
+				break
+			}
+			mantissa = quotient
+			exponent += binaryStep
+		}
+		return mantissa.String() + formatSuffix(BinarySI, exponent)
+	}
+
+	mantissa := new(big.Int).Set(q.nanos)
+	exponent := -nanoDigits
+	thousand := big.NewInt(int64(math.Pow10(decimalStep)))
+	remainder := new(big.Int)
+	for {
+		quotient, rest := new(big.Int).QuoRem(mantissa, thousand, remainder)
+		if rest.Sign() != 0 {
+			break
+		}
+		mantissa = quotient
+		exponent += decimalStep
+	}
+	return mantissa.String() + formatSuffix(format, exponent)
+}
+
+// canUseBinarySuffix tells whether the quantity is an integer not less
+// than 1024, in absolute value.
+func (q *Quantity) canUseBinarySuffix() bool {
+	scale := pow(decimalBase, nanoDigits)
+	if new(big.Int).Rem(q.nanos, scale).Sign() != 0 {
+		return false
+	}
+	return new(big.Int).Abs(q.integerValue()).Cmp(big.NewInt(binaryBase)) >= 0
+}
+
+// ToResource returns the quantity as a k8s-objects type, using its
+// canonical form.
+func (q *Quantity) ToResource() *resource.Quantity {
+	value := resource.Quantity(q.String())
+	return &value
+}
+
+// IsZero tells whether the quantity is zero.
+func (q *Quantity) IsZero() bool {
+	return q.nanos == nil || q.nanos.Sign() == 0
+}
+
+// Sign returns -1, 0 or +1, depending on the sign of the quantity.
+func (q *Quantity) Sign() int {
+	if q.nanos == nil {
+		return 0
+	}
+	return q.nanos.Sign()
+}
+
+// Cmp returns -1, 0 or +1 when the quantity is less than, equal to or
+// greater than y.
+func (q *Quantity) Cmp(y Quantity) int {
+	return q.value().Cmp(y.value())
+}
+
+// Equal tells whether the quantities have the same value, regardless of
+// their format.
+func (q *Quantity) Equal(y Quantity) bool {
+	return q.Cmp(y) == 0
+}
+
+// Add adds y to the quantity. The format of the quantity is kept, unless
+// the quantity is zero.
+func (q *Quantity) Add(y Quantity) {
+	if q.IsZero() && q.Format == "" {
+		q.Format = y.Format
+	}
+	q.nanos = new(big.Int).Add(q.value(), y.value())
+}
+
+// Sub subtracts y from the quantity.
+func (q *Quantity) Sub(y Quantity) {
+	if q.IsZero() && q.Format == "" {
+		q.Format = y.Format
+	}
+	q.nanos = new(big.Int).Sub(q.value(), y.value())
+}
+
+// Mul multiplies the quantity by y.
+func (q *Quantity) Mul(y int64) {
+	q.nanos = new(big.Int).Mul(q.value(), big.NewInt(y))
+}
+
+// Neg changes the sign of the quantity.
+func (q *Quantity) Neg() {
+	q.nanos = new(big.Int).Neg(q.value())
+}
+
+// Value returns the value of the quantity rounded up, away from zero, to
+// the nearest integer. Values not fitting into an int64 are capped.
+func (q *Quantity) Value() int64 {
+	return toInt64(divideRoundingUp(q.value(), pow(decimalBase, nanoDigits)))
+}
+
+// MilliValue returns the value of the quantity multiplied by 1000, rounded
+// up, away from zero, to the nearest integer. Values not fitting into an
+// int64 are capped.
+func (q *Quantity) MilliValue() int64 {
+	return toInt64(divideRoundingUp(q.value(), pow(decimalBase, nanoDigits-decimalStep)))
+}
+
+// AsApproximateFloat64 returns the value of the quantity as a float64,
+// which can lose precision. It's useful to compute ratios between
+// quantities.
+func (q *Quantity) AsApproximateFloat64() float64 {
+	value, _ := new(big.Rat).SetFrac(q.value(), pow(decimalBase, nanoDigits)).Float64()
+	return value
+}
+
+// MarshalJSON serializes the quantity as a JSON string, using its
+// canonical form.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(q.String())), nil
+}
+
+// UnmarshalJSON parses a quantity serialized as a JSON string or number.
+// `null` is the zero quantity.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		*q = Quantity{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	parsed, err := Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// value returns the value of the quantity, multiplied by 10^9.
+func (q *Quantity) value() *big.Int {
+	if q.nanos == nil {
+		return new(big.Int)
+	}
+	return q.nanos
+}
+
+// integerValue returns the value of the quantity, truncated.
+func (q *Quantity) integerValue() *big.Int {
+	return new(big.Int).Quo(q.value(), pow(decimalBase, nanoDigits))
+}
+
+func pow(base, exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(int64(exponent)), nil)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// divideRoundingUp divides the numbers, rounding the result away from
+// zero.
+func divideRoundingUp(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	if numerator.Sign()*denominator.Sign() > 0 {
+		return quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient.Sub(quotient, big.NewInt(1))
+}
+
+func toInt64(value *big.Int) int64 {
+	switch {
+	case value.IsInt64():
+		return value.Int64()
+	case value.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
@@ -0,0 +1,182 @@
+package quantity
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		quantity          string
+		expectedMilli     int64
+		expectedCanonical string
+		expectedFormat    Format
+	}{
+		"Zero":              {quantity: "0", expectedMilli: 0, expectedCanonical: "0", expectedFormat: DecimalSI},
+		"Integer":           {quantity: "2", expectedMilli: 2000, expectedCanonical: "2", expectedFormat: DecimalSI},
+		"Milli":             {quantity: "500m", expectedMilli: 500, expectedCanonical: "500m", expectedFormat: DecimalSI},
+		"Fraction":          {quantity: "0.5", expectedMilli: 500, expectedCanonical: "500m", expectedFormat: DecimalSI},
+		"MilliToInteger":    {quantity: "1000m", expectedMilli: 1000, expectedCanonical: "1", expectedFormat: DecimalSI},
+		"Kilo":              {quantity: "1500", expectedMilli: 1500000, expectedCanonical: "1500", expectedFormat: DecimalSI},
+		"Mega":              {quantity: "1.5M", expectedMilli: 1500000000, expectedCanonical: "1500k", expectedFormat: DecimalSI},
+		"Micro":             {quantity: "100u", expectedMilli: 1, expectedCanonical: "100u", expectedFormat: DecimalSI},
+		"Nano":              {quantity: "+7n", expectedMilli: 1, expectedCanonical: "7n", expectedFormat: DecimalSI},
+		"Negative":          {quantity: "-1.5", expectedMilli: -1500, expectedCanonical: "-1500m", expectedFormat: DecimalSI},
+		"Binary":            {quantity: "1Gi", expectedMilli: 1073741824000, expectedCanonical: "1Gi", expectedFormat: BinarySI},
+		"BinaryFraction":    {quantity: "1.5Gi", expectedMilli: 1610612736000, expectedCanonical: "1536Mi", expectedFormat: BinarySI},
+		"SmallBinary":       {quantity: "0.5Ki", expectedMilli: 512000, expectedCanonical: "512", expectedFormat: BinarySI},
+		"BinaryLessThanOne": {quantity: "0.0001Ki", expectedMilli: 103, expectedCanonical: "102400u", expectedFormat: DecimalSI},
+		"Exponent":          {quantity: "12e6", expectedMilli: 12000000000, expectedCanonical: "12e6", expectedFormat: DecimalExponent},
+		"NegativeExponent":  {quantity: "1E-3", expectedMilli: 1, expectedCanonical: "1e-3", expectedFormat: DecimalExponent},
+		"LargeExponent":     {quantity: "2e18", expectedMilli: math.MaxInt64, expectedCanonical: "2e18", expectedFormat: DecimalExponent},
+		"TinyExponent":      {quantity: "1e-2147483647", expectedMilli: 1, expectedCanonical: "1e-9", expectedFormat: DecimalExponent},
+		"ExaCapped":         {quantity: "1E", expectedMilli: math.MaxInt64, expectedCanonical: "1E", expectedFormat: DecimalSI},
+		"RoundedUp":         {quantity: "0.1n", expectedMilli: 1, expectedCanonical: "1n", expectedFormat: DecimalSI},
+		"TrailingDot":       {quantity: "5.", expectedMilli: 5000, expectedCanonical: "5", expectedFormat: DecimalSI},
+		"LeadingDot":        {quantity: ".5k", expectedMilli: 500000, expectedCanonical: "500", expectedFormat: DecimalSI},
+	} {
+		t.Run(description, func(t *testing.T) {
+			q, err := Parse(testCase.quantity)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if milli := q.MilliValue(); milli != testCase.expectedMilli {
+				t.Fatalf("expected %d milli units, got %d", testCase.expectedMilli, milli)
+			}
+			if canonical := q.String(); canonical != testCase.expectedCanonical {
+				t.Fatalf("expected %s, got %s", testCase.expectedCanonical, canonical)
+			}
+			if q.Format != testCase.expectedFormat {
+				t.Fatalf("expected format %s, got %s", testCase.expectedFormat, q.Format)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		quantity      string
+		expectedError error
+	}{
+		"Empty":            {quantity: "", expectedError: ErrFormatWrong},
+		"OnlySign":         {quantity: "-", expectedError: ErrFormatWrong},
+		"NoNumber":         {quantity: "Gi", expectedError: ErrFormatWrong},
+		"TwoDots":          {quantity: "1.2.3", expectedError: ErrFormatWrong},
+		"Space":            {quantity: " 1", expectedError: ErrFormatWrong},
+		"UppercaseKilo":    {quantity: "1K", expectedError: ErrSuffix},
+		"UnknownSuffix":    {quantity: "1Gb", expectedError: ErrSuffix},
+		"InvalidExponent":  {quantity: "1e3.5", expectedError: ErrSuffix},
+		"TrailingSpace":    {quantity: "1Gi ", expectedError: ErrSuffix},
+		"LowercaseBinary":  {quantity: "1gi", expectedError: ErrSuffix},
+		"ExponentWithSign": {quantity: "1e+", expectedError: ErrSuffix},
+		"ExponentRange":    {quantity: "1e2147483647", expectedError: ErrExponentRange},
+		"ExponentOverflow": {quantity: "1e2147483648", expectedError: ErrSuffix},
+	} {
+		t.Run(description, func(t *testing.T) {
+			if _, err := Parse(testCase.quantity); !errors.Is(err, testCase.expectedError) {
+				t.Fatalf("expected %v, got %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	q := MustParse("1Gi")
+	q.Add(MustParse("512Mi"))
+	if q.String() != "1536Mi" {
+		t.Fatalf("unexpected sum: %s", q.String())
+	}
+	q.Mul(2)
+	if q.String() != "3Gi" {
+		t.Fatalf("unexpected product: %s", q.String())
+	}
+	q.Sub(MustParse("3.5Gi"))
+	if q.String() != "-512Mi" || q.Sign() != -1 {
+		t.Fatalf("unexpected difference: %s", q.String())
+	}
+	q.Neg()
+	if q.Value() != 536870912 {
+		t.Fatalf("unexpected value: %d", q.Value())
+	}
+
+	var zero Quantity
+	zero.Add(MustParse("250m"))
+	if zero.String() != "250m" || zero.Format != DecimalSI {
+		t.Fatalf("unexpected sum: %s", zero.String())
+	}
+
+	cpu := NewMilliQuantity(1500, DecimalSI)
+	if cpu.Value() != 2 {
+		t.Fatalf("the value should be rounded up, got %d", cpu.Value())
+	}
+	half := MustParse("500m")
+	if ratio := cpu.AsApproximateFloat64() / half.AsApproximateFloat64(); ratio != 3 {
+		t.Fatalf("unexpected ratio: %f", ratio)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		a, b     string
+		expected int
+	}{
+		"Equal":          {a: "1", b: "1000m", expected: 0},
+		"DifferentUnits": {a: "1Gi", b: "1G", expected: 1},
+		"Less":           {a: "500m", b: "0.6", expected: -1},
+		"Negative":       {a: "-1", b: "0", expected: -1},
+		"Exponent":       {a: "1e3", b: "1k", expected: 0},
+	} {
+		t.Run(description, func(t *testing.T) {
+			a := MustParse(testCase.a)
+			if cmp := a.Cmp(MustParse(testCase.b)); cmp != testCase.expected {
+				t.Fatalf("expected %d, got %d", testCase.expected, cmp)
+			}
+		})
+	}
+
+	var zero Quantity
+	if !zero.Equal(MustParse("0")) || !zero.IsZero() {
+		t.Fatalf("the zero value should be 0")
+	}
+}
+
+func TestNewQuantity(t *testing.T) {
+	memory := NewQuantity(2*1024*1024*1024, BinarySI)
+	if memory.String() != "2Gi" {
+		t.Fatalf("unexpected quantity: %s", memory.String())
+	}
+	if value := *memory.ToResource(); value != "2Gi" {
+		t.Fatalf("unexpected resource: %s", value)
+	}
+	thousand := NewQuantity(1000, DecimalExponent)
+	if thousand.String() != "1e3" {
+		t.Fatalf("unexpected quantity: %s", thousand.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var requests struct {
+		CPU    Quantity `json:"cpu"`
+		Memory Quantity `json:"memory"`
+		Pods   Quantity `json:"pods"`
+		GPU    Quantity `json:"gpu"`
+	}
+	err := json.Unmarshal([]byte(`{"cpu": "0.5", "memory": "1024Mi", "pods": 110, "gpu": null}`), &requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	serialized, err := json.Marshal(requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"cpu":"500m","memory":"1Gi","pods":"110","gpu":"0"}`; string(serialized) != expected {
+		t.Fatalf("unexpected serialization: %s", serialized)
+	}
+
+	if err = json.Unmarshal([]byte(`{"cpu": "one"}`), &requests); !errors.Is(err, ErrFormatWrong) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
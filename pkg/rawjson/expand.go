@@ -0,0 +1,62 @@
+package rawjson
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Expand returns the paths of all the values of the document matched by
+// the pattern, which can contain wildcards. The paths are returned in the
+// order the values appear inside of the document, and they never contain
+// wildcards.
+//
+// Values that don't exist are not returned, nor are the ones that cannot be
+// reached because the document has a different structure, e.g. `a[*]` when
+// `a` is an object.
+func Expand(document []byte, pattern Path) ([]Path, error) {
+	if !json.Valid(document) {
+		return nil, errors.New("invalid JSON document")
+	}
+
+	start := skipWhitespace(document, 0)
+	matches := []Path{}
+	if err := expand(document, start, pattern, Path{}, &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func expand(document []byte, start int, pattern Path, current Path, matches *[]Path) error {
+	if len(pattern) == 0 {
+		*matches = append(*matches, current)
+		return nil
+	}
+
+	segment := pattern[0]
+	if (segment.IsIndex && document[start] != '[') || (!segment.IsIndex && document[start] != '{') {
+		return nil
+	}
+	c, err := scanContainer(document, start)
+	if err != nil {
+		return err
+	}
+
+	if !segment.Wildcard {
+		i := c.find(segment)
+		if i == -1 {
+			return nil
+		}
+		return expand(document, c.items[i].valueStart, pattern[1:], current.append(segment), matches)
+	}
+
+	for i, it := range c.items {
+		next := current.Key(it.key)
+		if segment.IsIndex {
+			next = current.Index(i)
+		}
+		if err = expand(document, it.valueStart, pattern[1:], next, matches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
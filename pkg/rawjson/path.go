@@ -0,0 +1,195 @@
+package rawjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wildcard is the string representation of the wildcard segments.
+const wildcard = "*"
+
+// Segment is a single step of a Path: either the key of an object member
+// or the index of an array element.
+type Segment struct {
+	// Key of the object member, used when IsIndex is false
+	Key string
+	// Index of the array element, used when IsIndex is true
+	Index int
+	// IsIndex tells whether the segment refers to an array element
+	IsIndex bool
+	// Wildcard tells whether the segment matches all the array elements,
+	// when IsIndex is true, or all the object members otherwise
+	Wildcard bool
+}
+
+// Path identifies a value inside of a JSON document.
+//
+// Paths are written using the dot notation used by Kubernetes, e.g.
+// `spec.template.spec.containers[0].securityContext`. Keys that contain
+// dots or brackets must be quoted, e.g.
+// `metadata.annotations["kubernetes.io/description"]`.
+//
+// Paths can contain wildcards, which are accepted only by Expand: `[*]`
+// matches all the elements of an array and `*` all the members of an
+// object, e.g. `spec.containers[*].image` or `metadata.labels.*`. A key
+// named `*` must be quoted: `metadata.labels["*"]`.
+//
+// The empty path refers to the whole document.
+type Path []Segment
+
+// ParsePath parses the string representation of a Path.
+func ParsePath(path string) (Path, error) {
+	segments := Path{}
+	i := 0
+	for i < len(path) {
+		switch {
+		case path[i] == '[':
+			segment, end, err := parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			i = end
+		case path[i] == '.':
+			if i == 0 || i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, fmt.Errorf("invalid path %q: unexpected '.' at position %d", path, i)
+			}
+			i++
+		default:
+			if i > 0 && path[i-1] != '.' {
+				return nil, fmt.Errorf("invalid path %q: expected '.' or '[' at position %d", path, i)
+			}
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			segments = append(segments, keySegment(path[i:end]))
+			i = end
+		}
+	}
+	return segments, nil
+}
+
+// keySegment returns the segment of an unquoted key, which is a wildcard
+// when the key is `*`.
+func keySegment(key string) Segment {
+	if key == wildcard {
+		return Segment{Wildcard: true}
+	}
+	return Segment{Key: key}
+}
+
+// parseBracket parses a `[<index>]` or `["<key>"]` segment starting at
+// `start`. It returns the segment and the position right after it.
+func parseBracket(path string, start int) (Segment, int, error) {
+	if start+1 < len(path) && path[start+1] == '"' {
+		end := start + 2
+		for end < len(path) && path[end] != '"' {
+			if path[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end+1 >= len(path) || path[end+1] != ']' {
+			return Segment{}, 0, fmt.Errorf("invalid path %q: unterminated quoted key at position %d", path, start)
+		}
+		key := ""
+		if err := json.Unmarshal([]byte(path[start+1:end+1]), &key); err != nil {
+			return Segment{}, 0, fmt.Errorf("invalid path %q: invalid quoted key at position %d: %w", path, start, err)
+		}
+		return Segment{Key: key}, end + 2, nil
+	}
+
+	end := strings.IndexByte(path[start:], ']')
+	if end == -1 {
+		return Segment{}, 0, fmt.Errorf("invalid path %q: unterminated index at position %d", path, start)
+	}
+	end += start
+	if path[start+1:end] == wildcard {
+		return Segment{IsIndex: true, Wildcard: true}, end + 1, nil
+	}
+	index, err := strconv.Atoi(path[start+1 : end])
+	if err != nil || index < 0 {
+		return Segment{}, 0, fmt.Errorf("invalid path %q: invalid index %q", path, path[start+1:end])
+	}
+	return Segment{Index: index, IsIndex: true}, end + 1, nil
+}
+
+// MustParsePath is like ParsePath, but it panics when the path is not
+// valid. It's meant to be used with constant paths.
+func MustParsePath(path string) Path {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// HasWildcards tells whether the path contains wildcard segments.
+func (p Path) HasWildcards() bool {
+	for _, segment := range p {
+		if segment.Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns a new path that refers to the given member of the object
+// referenced by p.
+func (p Path) Key(key string) Path {
+	return p.append(Segment{Key: key})
+}
+
+// Index returns a new path that refers to the given element of the array
+// referenced by p.
+func (p Path) Index(index int) Path {
+	return p.append(Segment{Index: index, IsIndex: true})
+}
+
+// Join returns a new path made by the segments of p followed by the ones
+// of other.
+func (p Path) Join(other Path) Path {
+	return p.append(other...)
+}
+
+func (p Path) append(segments ...Segment) Path {
+	joined := make(Path, 0, len(p)+len(segments))
+	joined = append(joined, p...)
+	return append(joined, segments...)
+}
+
+// String returns the string representation of the path, which can be
+// parsed back with ParsePath.
+func (p Path) String() string {
+	builder := strings.Builder{}
+	for i, segment := range p {
+		switch {
+		case segment.IsIndex && segment.Wildcard:
+			builder.WriteString("[" + wildcard + "]")
+		case segment.IsIndex:
+			builder.WriteString("[")
+			builder.WriteString(strconv.Itoa(segment.Index))
+			builder.WriteString("]")
+		case segment.Wildcard:
+			if i > 0 {
+				builder.WriteString(".")
+			}
+			builder.WriteString(wildcard)
+		case segment.Key == "" || segment.Key == wildcard || strings.ContainsAny(segment.Key, `.[]"\`):
+			// json.Marshal cannot fail when serializing a string
+			quoted, _ := json.Marshal(segment.Key)
+			builder.WriteString("[")
+			builder.Write(quoted)
+			builder.WriteString("]")
+		default:
+			if i > 0 {
+				builder.WriteString(".")
+			}
+			builder.WriteString(segment.Key)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,245 @@
+// This package edits JSON documents in place, without decoding them into
+// Go types and encoding them back.
+//
+// Decoding a Kubernetes object into a Go struct and serializing it again
+// drops all the fields unknown to the struct, for example the ones added by
+// newer versions of Kubernetes or by CRDs. The functions of this package
+// change only the bytes of the targeted value: the rest of the document is
+// left untouched, including the order of the keys and the formatting.
+package rawjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// location is the result of walking a document following a path.
+type location struct {
+	// span of the deepest value that has been reached
+	start, end int
+	// number of path segments that have been followed
+	depth int
+}
+
+// locate follows the path as far as possible. Walking stops when a
+// segment cannot be found, or when a `null` value is reached.
+func locate(document []byte, path Path) (location, error) {
+	if !json.Valid(document) {
+		return location{}, errors.New("invalid JSON document")
+	}
+	if path.HasWildcards() {
+		return location{}, fmt.Errorf("path %q contains wildcards", path.String())
+	}
+
+	start := skipWhitespace(document, 0)
+	end, err := scanValue(document, start)
+	if err != nil {
+		return location{}, err
+	}
+
+	for depth, segment := range path {
+		if document[start] == 'n' {
+			return location{start: start, end: end, depth: depth}, nil
+		}
+		if err = checkContainer(document, start, segment, path[:depth]); err != nil {
+			return location{}, err
+		}
+
+		var c container
+		if c, err = scanContainer(document, start); err != nil {
+			return location{}, err
+		}
+		i := c.find(segment)
+		if i == -1 {
+			return location{start: start, end: end, depth: depth}, nil
+		}
+		start, end = c.items[i].valueStart, c.items[i].end
+	}
+
+	return location{start: start, end: end, depth: len(path)}, nil
+}
+
+// checkContainer ensures the value starting at `start` can be traversed
+// using the given segment.
+func checkContainer(document []byte, start int, segment Segment, parent Path) error {
+	switch {
+	case segment.IsIndex && document[start] != '[':
+		return fmt.Errorf("cannot access index %d of %q: not an array", segment.Index, parent.String())
+	case !segment.IsIndex && document[start] != '{':
+		return fmt.Errorf("cannot access key %q of %q: not an object", segment.Key, parent.String())
+	default:
+		return nil
+	}
+}
+
+// Get returns the raw value referenced by the path. The returned boolean
+// is false when the value doesn't exist.
+func Get(document []byte, path Path) (json.RawMessage, bool, error) {
+	loc, err := locate(document, path)
+	if err != nil {
+		return nil, false, err
+	}
+	if loc.depth < len(path) {
+		return nil, false, nil
+	}
+	return json.RawMessage(document[loc.start:loc.end]), true, nil
+}
+
+// Set serializes the value to JSON and stores it at the location
+// referenced by the path, see SetRaw.
+func Set(document []byte, path Path, value interface{}) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize value: %w", err)
+	}
+	return SetRaw(document, path, raw)
+}
+
+// SetRaw stores the raw value at the location referenced by the path and
+// returns the updated document.
+//
+// Missing objects along the path are created, as well as objects that are
+// `null`. Missing array elements are not created, with the exception of the
+// element right after the last one, which is appended.
+func SetRaw(document []byte, path Path, value json.RawMessage) ([]byte, error) {
+	if !json.Valid(value) {
+		return nil, errors.New("invalid JSON value")
+	}
+
+	loc, err := locate(document, path)
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth == len(path) {
+		return splice(document, loc.start, loc.end, value), nil
+	}
+
+	segment := path[loc.depth]
+	missing, err := buildValue(path[loc.depth+1:], value)
+	if err != nil {
+		return nil, err
+	}
+
+	if document[loc.start] == 'n' {
+		if segment.IsIndex {
+			return nil, fmt.Errorf("cannot set index %d of %q: the array doesn't exist",
+				segment.Index, path[:loc.depth].String())
+		}
+		member, memberErr := buildMember(segment.Key, missing)
+		if memberErr != nil {
+			return nil, memberErr
+		}
+		return splice(document, loc.start, loc.end, []byte("{"+string(member)+"}")), nil
+	}
+
+	c, err := scanContainer(document, loc.start)
+	if err != nil {
+		return nil, err
+	}
+	if segment.IsIndex {
+		if segment.Index != len(c.items) {
+			return nil, fmt.Errorf("cannot set index %d of %q: the array has %d elements",
+				segment.Index, path[:loc.depth].String(), len(c.items))
+		}
+		return insert(document, c, missing), nil
+	}
+
+	member, err := buildMember(segment.Key, missing)
+	if err != nil {
+		return nil, err
+	}
+	return insert(document, c, member), nil
+}
+
+// Delete removes the value referenced by the path and returns the updated
+// document. Deleting a value that doesn't exist is not an error.
+func Delete(document []byte, path Path) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot delete the whole document")
+	}
+
+	loc, err := locate(document, path)
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth < len(path) {
+		return document, nil
+	}
+
+	parent, err := locate(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	c, err := scanContainer(document, parent.start)
+	if err != nil {
+		return nil, err
+	}
+	start, end := c.removalSpan(c.find(path[len(path)-1]))
+	return splice(document, start, end, nil), nil
+}
+
+// Append serializes the value to JSON and adds it at the end of the array
+// referenced by the path. The array is created when it doesn't exist or
+// when it's `null`.
+func Append(document []byte, path Path, value interface{}) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize value: %w", err)
+	}
+
+	loc, err := locate(document, path)
+	if err != nil {
+		return nil, err
+	}
+	if loc.depth < len(path) || document[loc.start] == 'n' {
+		return SetRaw(document, path, []byte("["+string(raw)+"]"))
+	}
+	if document[loc.start] != '[' {
+		return nil, fmt.Errorf("cannot append to %q: not an array", path.String())
+	}
+
+	c, err := scanContainer(document, loc.start)
+	if err != nil {
+		return nil, err
+	}
+	return insert(document, c, raw), nil
+}
+
+// buildValue wraps the value into the objects described by the path.
+func buildValue(path Path, value json.RawMessage) (json.RawMessage, error) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].IsIndex {
+			return nil, fmt.Errorf("cannot create index %d: the array doesn't exist", path[i].Index)
+		}
+		member, err := buildMember(path[i].Key, value)
+		if err != nil {
+			return nil, err
+		}
+		value = []byte("{" + string(member) + "}")
+	}
+	return value, nil
+}
+
+func buildMember(key string, value json.RawMessage) ([]byte, error) {
+	rawKey, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(string(rawKey) + ":" + string(value)), nil
+}
+
+// insert adds the item at the end of the container.
+func insert(document []byte, c container, value []byte) []byte {
+	position, separator := c.insertionPoint()
+	return splice(document, position, position, []byte(separator+string(value)))
+}
+
+// splice returns a copy of the document where the bytes between `start`
+// and `end` are replaced by `value`.
+func splice(document []byte, start, end int, value []byte) []byte {
+	result := make([]byte, 0, len(document)-(end-start)+len(value))
+	result = append(result, document[:start]...)
+	result = append(result, value...)
+	return append(result, document[end:]...)
+}
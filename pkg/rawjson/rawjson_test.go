@@ -0,0 +1,446 @@
+package rawjson
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const deployment = `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "annotations": {"kubernetes.io/description": "web"}},
+  "spec": {
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx", "futureField": {"alpha": true}},
+          {"name": "sidecar", "image": "busybox", "securityContext": null}
+        ],
+        "newPodField": 42
+      }
+    }
+  }
+}`
+
+func TestParsePath(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		path          string
+		expectedPath  Path
+		expectedError string
+	}{
+		"Empty": {
+			path:         "",
+			expectedPath: Path{},
+		},
+		"Keys": {
+			path:         "spec.template.spec",
+			expectedPath: Path{{Key: "spec"}, {Key: "template"}, {Key: "spec"}},
+		},
+		"Indexes": {
+			path:         "spec.containers[0].ports[12]",
+			expectedPath: Path{{Key: "spec"}, {Key: "containers"}, {IsIndex: true}, {Key: "ports"}, {Index: 12, IsIndex: true}},
+		},
+		"QuotedKey": {
+			path:         `metadata.annotations["kubernetes.io/description"].foo`,
+			expectedPath: Path{{Key: "metadata"}, {Key: "annotations"}, {Key: "kubernetes.io/description"}, {Key: "foo"}},
+		},
+		"QuotedKeyWithEscapes": {
+			path:         `["a\"b]"]`,
+			expectedPath: Path{{Key: `a"b]`}},
+		},
+		"Wildcards": {
+			path:         "spec.containers[*].env.*",
+			expectedPath: Path{{Key: "spec"}, {Key: "containers"}, {IsIndex: true, Wildcard: true}, {Key: "env"}, {Wildcard: true}},
+		},
+		"QuotedWildcardKey": {
+			path:         `labels["*"]`,
+			expectedPath: Path{{Key: "labels"}, {Key: "*"}},
+		},
+		"LeadingDot": {
+			path:          ".spec",
+			expectedError: `invalid path ".spec": unexpected '.' at position 0`,
+		},
+		"DoubleDot": {
+			path:          "spec..template",
+			expectedError: `invalid path "spec..template": unexpected '.' at position 4`,
+		},
+		"MissingDotAfterIndex": {
+			path:          "containers[0]image",
+			expectedError: `invalid path "containers[0]image": expected '.' or '[' at position 13`,
+		},
+		"InvalidIndex": {
+			path:          "containers[-1]",
+			expectedError: `invalid path "containers[-1]": invalid index "-1"`,
+		},
+		"UnterminatedQuotedKey": {
+			path:          `annotations["foo`,
+			expectedError: `invalid path "annotations[\"foo": unterminated quoted key at position 11`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			path, err := ParsePath(testCase.path)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expectedPath, path); diff != "" {
+				t.Fatalf("unexpected path:\n%s", diff)
+			}
+
+			reparsed, err := ParsePath(path.String())
+			if err != nil {
+				t.Fatalf("cannot parse %q: %v", path.String(), err)
+			}
+			if diff := cmp.Diff(path, reparsed); diff != "" {
+				t.Fatalf("String() is not the inverse of ParsePath:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		path          string
+		expectedValue string
+		expectedFound bool
+		expectedError string
+	}{
+		"Object": {
+			path:          "spec.template.spec.containers[0].futureField",
+			expectedValue: `{"alpha": true}`,
+			expectedFound: true,
+		},
+		"QuotedKey": {
+			path:          `metadata.annotations["kubernetes.io/description"]`,
+			expectedValue: `"web"`,
+			expectedFound: true,
+		},
+		"Null": {
+			path:          "spec.template.spec.containers[1].securityContext",
+			expectedValue: `null`,
+			expectedFound: true,
+		},
+		"MissingKey": {
+			path: "spec.template.spec.volumes",
+		},
+		"MissingIndex": {
+			path: "spec.template.spec.containers[2].image",
+		},
+		"TraverseNull": {
+			path: "spec.template.spec.containers[1].securityContext.privileged",
+		},
+		"NotAnArray": {
+			path:          "spec.template[0]",
+			expectedError: `cannot access index 0 of "spec.template": not an array`,
+		},
+		"NotAnObject": {
+			path:          "kind.foo",
+			expectedError: `cannot access key "foo" of "kind": not an object`,
+		},
+		"Wildcard": {
+			path:          "spec.template.spec.containers[*].image",
+			expectedError: `path "spec.template.spec.containers[*].image" contains wildcards`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			value, found, err := Get([]byte(deployment), MustParsePath(testCase.path))
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found != testCase.expectedFound {
+				t.Fatalf("expected found to be %t", testCase.expectedFound)
+			}
+			if string(value) != testCase.expectedValue {
+				t.Fatalf("unexpected value: %s", value)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		pattern       string
+		expectedPaths []string
+	}{
+		"NoWildcards": {
+			pattern:       "metadata.name",
+			expectedPaths: []string{"metadata.name"},
+		},
+		"Missing": {
+			pattern:       "metadata.labels",
+			expectedPaths: []string{},
+		},
+		"ArrayElements": {
+			pattern: "spec.template.spec.containers[*].image",
+			expectedPaths: []string{
+				"spec.template.spec.containers[0].image",
+				"spec.template.spec.containers[1].image",
+			},
+		},
+		"ObjectMembers": {
+			pattern:       "metadata.annotations.*",
+			expectedPaths: []string{`metadata.annotations["kubernetes.io/description"]`},
+		},
+		"PartialMatches": {
+			pattern:       "spec.template.spec.containers[*].futureField.*",
+			expectedPaths: []string{"spec.template.spec.containers[0].futureField.alpha"},
+		},
+		"DifferentStructure": {
+			pattern:       "spec.template.spec.newPodField[*]",
+			expectedPaths: []string{},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			paths, err := Expand([]byte(deployment), MustParsePath(testCase.pattern))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expanded := []string{}
+			for _, path := range paths {
+				expanded = append(expanded, path.String())
+			}
+			if diff := cmp.Diff(testCase.expectedPaths, expanded); diff != "" {
+				t.Fatalf("unexpected paths:\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Expand([]byte(`{`), MustParsePath("a")); err == nil {
+		t.Fatalf("expected an error for the invalid document")
+	}
+}
+
+func TestSet(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		document         string
+		path             string
+		value            interface{}
+		expectedDocument string
+		expectedError    string
+	}{
+		"ReplaceValue": {
+			document:         `{"a": {"b": 1, "c": [1, 2]}, "z": true}`,
+			path:             "a.b",
+			value:            map[string]string{"x": "y"},
+			expectedDocument: `{"a": {"b": {"x":"y"}, "c": [1, 2]}, "z": true}`,
+		},
+		"ReplaceArrayElement": {
+			document:         `{"a": [1, 2, 3]}`,
+			path:             "a[1]",
+			value:            "two",
+			expectedDocument: `{"a": [1, "two", 3]}`,
+		},
+		"AddMember": {
+			document:         `{"a": {"b": 1}}`,
+			path:             "a.c",
+			value:            2,
+			expectedDocument: `{"a": {"b": 1,"c":2}}`,
+		},
+		"AddMemberToEmptyObject": {
+			document:         `{"a": { }}`,
+			path:             "a.c",
+			value:            2,
+			expectedDocument: `{"a": {"c":2 }}`,
+		},
+		"CreateIntermediateObjects": {
+			document:         `{"a": {}}`,
+			path:             "a.b.c",
+			value:            true,
+			expectedDocument: `{"a": {"b":{"c":true}}}`,
+		},
+		"ReplaceNullWithObject": {
+			document:         `{"a": null}`,
+			path:             `a["x.y"].z`,
+			value:            1,
+			expectedDocument: `{"a": {"x.y":{"z":1}}}`,
+		},
+		"AppendWithIndex": {
+			document:         `{"a": [1]}`,
+			path:             "a[1]",
+			value:            2,
+			expectedDocument: `{"a": [1,2]}`,
+		},
+		"ReplaceDocument": {
+			document:         ` {"a": 1} `,
+			path:             "",
+			value:            []int{1},
+			expectedDocument: ` [1] `,
+		},
+		"IndexOutOfRange": {
+			document:      `{"a": [1]}`,
+			path:          "a[3]",
+			value:         2,
+			expectedError: `cannot set index 3 of "a": the array has 1 elements`,
+		},
+		"MissingArray": {
+			document:      `{"a": {}}`,
+			path:          "a.b[0].c",
+			value:         2,
+			expectedError: `cannot create index 0: the array doesn't exist`,
+		},
+		"InvalidDocument": {
+			document:      `{"a": }`,
+			path:          "a",
+			value:         2,
+			expectedError: `invalid JSON document`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			document, err := Set([]byte(testCase.document), MustParsePath(testCase.path), testCase.value)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(document) != testCase.expectedDocument {
+				t.Fatalf("unexpected document: %s", document)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		document         string
+		path             string
+		expectedDocument string
+	}{
+		"FirstMember": {
+			document:         `{"a": 1, "b": 2, "c": 3}`,
+			path:             "a",
+			expectedDocument: `{"b": 2, "c": 3}`,
+		},
+		"MiddleMember": {
+			document:         `{"a": 1, "b": 2, "c": 3}`,
+			path:             "b",
+			expectedDocument: `{"a": 1, "c": 3}`,
+		},
+		"LastMember": {
+			document:         `{"a": 1, "b": 2, "c": 3}`,
+			path:             "c",
+			expectedDocument: `{"a": 1, "b": 2}`,
+		},
+		"OnlyMember": {
+			document:         `{"a": {"b": [1]}}`,
+			path:             "a.b",
+			expectedDocument: `{"a": {}}`,
+		},
+		"ArrayElement": {
+			document:         `{"a": [1, 2, 3]}`,
+			path:             "a[1]",
+			expectedDocument: `{"a": [1, 3]}`,
+		},
+		"Missing": {
+			document:         `{"a": [1, 2, 3]}`,
+			path:             "b.c",
+			expectedDocument: `{"a": [1, 2, 3]}`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			document, err := Delete([]byte(testCase.document), MustParsePath(testCase.path))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(document) != testCase.expectedDocument {
+				t.Fatalf("unexpected document: %s", document)
+			}
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		document         string
+		path             string
+		expectedDocument string
+		expectedError    string
+	}{
+		"ExistingArray": {
+			document:         `{"a": [1, 2]}`,
+			path:             "a",
+			expectedDocument: `{"a": [1, 2,"new"]}`,
+		},
+		"EmptyArray": {
+			document:         `{"a": []}`,
+			path:             "a",
+			expectedDocument: `{"a": ["new"]}`,
+		},
+		"NullArray": {
+			document:         `{"a": null}`,
+			path:             "a",
+			expectedDocument: `{"a": ["new"]}`,
+		},
+		"MissingArray": {
+			document:         `{"a": {}}`,
+			path:             "a.b",
+			expectedDocument: `{"a": {"b":["new"]}}`,
+		},
+		"NotAnArray": {
+			document:      `{"a": {}}`,
+			path:          "a",
+			expectedError: `cannot append to "a": not an array`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			document, err := Append([]byte(testCase.document), MustParsePath(testCase.path), "new")
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(document) != testCase.expectedDocument {
+				t.Fatalf("unexpected document: %s", document)
+			}
+		})
+	}
+}
+
+func TestSetPreservesUnknownFields(t *testing.T) {
+	document, err := Set(
+		[]byte(deployment),
+		MustParsePath("spec.template.spec.containers[1].securityContext"),
+		map[string]bool{"runAsNonRoot": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "annotations": {"kubernetes.io/description": "web"}},
+  "spec": {
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx", "futureField": {"alpha": true}},
+          {"name": "sidecar", "image": "busybox", "securityContext": {"runAsNonRoot":true}}
+        ],
+        "newPodField": 42
+      }
+    }
+  }
+}`
+	if diff := cmp.Diff(expected, string(document)); diff != "" {
+		t.Fatalf("unexpected document:\n%s", diff)
+	}
+}
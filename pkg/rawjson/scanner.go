@@ -0,0 +1,204 @@
+package rawjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var errUnexpectedEnd = errors.New("unexpected end of JSON input")
+
+// item is the location of an object member or of an array element inside
+// of a JSON document.
+type item struct {
+	// position of the first byte of the item: the opening quote of the key
+	// for object members, the first byte of the value for array elements
+	start int
+	// position of the first byte of the value
+	valueStart int
+	// position right after the last byte of the value
+	end int
+	// unescaped key, set only for object members
+	key string
+}
+
+// container is the location of an object or of an array inside of a JSON
+// document.
+type container struct {
+	// position of the opening `{` or `[`
+	open int
+	// position of the closing `}` or `]`
+	close int
+	items []item
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipWhitespace(data []byte, pos int) int {
+	for pos < len(data) && isWhitespace(data[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// scanValue returns the position right after the JSON value starting at
+// `pos`.
+func scanValue(data []byte, pos int) (int, error) {
+	if pos >= len(data) {
+		return 0, errUnexpectedEnd
+	}
+
+	switch data[pos] {
+	case '"':
+		return scanString(data, pos)
+	case '{', '[':
+		c, err := scanContainer(data, pos)
+		if err != nil {
+			return 0, err
+		}
+		return c.close + 1, nil
+	default:
+		end := pos
+		for end < len(data) && !isWhitespace(data[end]) &&
+			data[end] != ',' && data[end] != '}' && data[end] != ']' {
+			end++
+		}
+		if end == pos {
+			return 0, fmt.Errorf("unexpected character %q at position %d", data[pos], pos)
+		}
+		return end, nil
+	}
+}
+
+// scanString returns the position right after the JSON string starting at
+// `pos`.
+func scanString(data []byte, pos int) (int, error) {
+	for i := pos + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errUnexpectedEnd
+}
+
+// scanContainer locates all the items of the object or array starting at
+// `pos`.
+func scanContainer(data []byte, pos int) (container, error) {
+	isObject := data[pos] == '{'
+	closing := byte(']')
+	if isObject {
+		closing = '}'
+	}
+
+	c := container{open: pos}
+	i := skipWhitespace(data, pos+1)
+	if i < len(data) && data[i] == closing {
+		c.close = i
+		return c, nil
+	}
+
+	for {
+		it := item{start: i}
+		if isObject {
+			key, valueStart, err := scanKey(data, i)
+			if err != nil {
+				return container{}, err
+			}
+			it.key = key
+			i = valueStart
+		}
+
+		it.valueStart = i
+		end, err := scanValue(data, i)
+		if err != nil {
+			return container{}, err
+		}
+		it.end = end
+		c.items = append(c.items, it)
+
+		i = skipWhitespace(data, end)
+		if i >= len(data) {
+			return container{}, errUnexpectedEnd
+		}
+		switch data[i] {
+		case ',':
+			i = skipWhitespace(data, i+1)
+		case closing:
+			c.close = i
+			return c, nil
+		default:
+			return container{}, fmt.Errorf("unexpected character %q at position %d", data[i], i)
+		}
+	}
+}
+
+// scanKey parses the key of the object member starting at `pos`. It
+// returns the unescaped key and the position of the member value.
+func scanKey(data []byte, pos int) (string, int, error) {
+	if pos >= len(data) || data[pos] != '"' {
+		return "", 0, fmt.Errorf("expected object key at position %d", pos)
+	}
+	end, err := scanString(data, pos)
+	if err != nil {
+		return "", 0, err
+	}
+
+	rawKey := data[pos+1 : end-1]
+	key := string(rawKey)
+	if bytes.IndexByte(rawKey, '\\') != -1 {
+		if err = json.Unmarshal(data[pos:end], &key); err != nil {
+			return "", 0, fmt.Errorf("invalid object key at position %d: %w", pos, err)
+		}
+	}
+
+	i := skipWhitespace(data, end)
+	if i >= len(data) || data[i] != ':' {
+		return "", 0, fmt.Errorf("expected ':' at position %d", i)
+	}
+	return key, skipWhitespace(data, i+1), nil
+}
+
+// find returns the index of the item matching the segment, or -1.
+func (c container) find(segment Segment) int {
+	if segment.IsIndex {
+		if segment.Index < len(c.items) {
+			return segment.Index
+		}
+		return -1
+	}
+	for i, it := range c.items {
+		if it.key == segment.Key {
+			return i
+		}
+	}
+	return -1
+}
+
+// removalSpan returns the portion of the document to cut in order to
+// remove the i-th item, together with the comma that separates it from the
+// other items.
+func (c container) removalSpan(i int) (int, int) {
+	switch {
+	case i > 0:
+		return c.items[i-1].end, c.items[i].end
+	case len(c.items) > 1:
+		return c.items[0].start, c.items[1].start
+	default:
+		return c.items[0].start, c.items[0].end
+	}
+}
+
+// insertionPoint returns the position where a new item has to be added
+// and the separator to put in front of it.
+func (c container) insertionPoint() (int, string) {
+	if len(c.items) == 0 {
+		return c.open + 1, ""
+	}
+	return c.items[len(c.items)-1].end, ","
+}
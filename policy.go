@@ -0,0 +1,167 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/protocol"
+	wapc "github.com/wapc/wapc-guest-tinygo"
+)
+
+// DecodingErrorCode is the error code used when the incoming payload, or the
+// settings it carries, cannot be decoded.
+const DecodingErrorCode Code = 400
+
+// Policy is the interface implemented by policies that let the SDK take care
+// of the waPC plumbing. See `Register`.
+//
+// `S` is the type of the policy settings. The settings provided by the user
+// are decoded into it using `DecodeSettings`: the settings struct can define
+// default values and implement the `Validator` interface.
+type Policy[S any] interface {
+	// Validate evaluates the incoming request using the given settings.
+	// The helper functions of this package (`AcceptRequest`,
+	// `RejectRequest`, `MutateRequest`,...) can be used to build the
+	// response. Returning an error rejects the request, see
+	// `RejectionError`.
+	Validate(request protocol.ValidationRequest, settings S) ([]byte, error)
+
+	// ValidateSettings checks the settings provided by the user. Returning
+	// an error marks the settings as invalid, the error message is shown to
+	// the user.
+	//
+	// This method is invoked only by the `validate_settings` function.
+	// Checks that must be done by the `validate` function too belong to
+	// the `Validator` interface of the settings.
+	ValidateSettings(settings S) error
+}
+
+// RejectionError is an error that, when returned by `Policy.Validate`,
+// rejects the request using the given message and error code.
+//
+// Any other error rejects the request using the error message and no
+// error code.
+type RejectionError struct {
+	Message Message
+	Code    Code
+}
+
+// NewRejectionError creates a new `RejectionError`
+// * `message`: optional message to show to the user
+// * `code`: optional error code to show to the user.
+func NewRejectionError(message Message, code Code) *RejectionError {
+	return &RejectionError{
+		Message: message,
+		Code:    code,
+	}
+}
+
+func (e *RejectionError) Error() string {
+	return string(e.Message)
+}
+
+// Register registers the `validate` and `validate_settings` waPC functions
+// of the given policy. It must be invoked inside of the `main` function of
+// the policy:
+//
+//	func main() {
+//		sdk.Register[Settings](&MyPolicy{})
+//	}
+//
+// The `protocol_version` function is automatically registered by the
+// `protocol` package.
+//
+// The options are used when decoding the settings, see `DecodeSettings`.
+func Register[S any](policy Policy[S], opts ...SettingsOption) {
+	wapc.RegisterFunctions(wapc.Functions{
+		"validate": func(payload []byte) ([]byte, error) {
+			return ValidatePayload(policy, payload, opts...)
+		},
+		"validate_settings": func(payload []byte) ([]byte, error) {
+			return ValidateSettingsPayload(policy, payload, opts...)
+		},
+	})
+}
+
+// ValidatePayload implements the `validate` waPC function on behalf of the
+// given policy. The payload is decoded into a `protocol.ValidationRequest`,
+// the settings are decoded into `S` using `DecodeSettings` and then
+// `Policy.Validate` is invoked.
+//
+// Decoding errors, invalid settings and errors returned by the policy are
+// turned into rejection responses.
+//
+// This function is useful to write unit tests of policies implementing the
+// `Policy` interface.
+func ValidatePayload[S any](policy Policy[S], payload []byte, opts ...SettingsOption) ([]byte, error) {
+	validationRequest := protocol.ValidationRequest{}
+	if err := json.Unmarshal(payload, &validationRequest); err != nil {
+		return RejectRequest(
+			Message(fmt.Sprintf("cannot decode validation request: %s", err)),
+			DecodingErrorCode)
+	}
+
+	settings, err := DecodeSettings[S](validationRequest.Settings, opts...)
+	if err != nil {
+		var settingsErr *SettingsError
+		if errors.As(err, &settingsErr) {
+			return RejectRequest(
+				Message(fmt.Sprintf("invalid settings: %s", settingsErr)),
+				DecodingErrorCode)
+		}
+		return RejectRequest(
+			Message(fmt.Sprintf("cannot decode settings: %s", err)),
+			DecodingErrorCode)
+	}
+
+	response, err := policy.Validate(validationRequest, settings)
+	if err != nil {
+		return rejectRequestFromError(err)
+	}
+
+	return response, nil
+}
+
+// ValidateSettingsPayload implements the `validate_settings` waPC function
+// on behalf of the given policy. The payload is decoded into `S` using
+// `DecodeSettings` and then `Policy.ValidateSettings` is invoked. All the
+// problems found are reported inside of a single message, separated by
+// `; `.
+//
+// This function is useful to write unit tests of policies implementing the
+// `Policy` interface.
+func ValidateSettingsPayload[S any](policy Policy[S], payload []byte, opts ...SettingsOption) ([]byte, error) {
+	problems := []string{}
+
+	settings, err := DecodeSettings[S](payload, opts...)
+	if err != nil {
+		var settingsErr *SettingsError
+		if !errors.As(err, &settingsErr) {
+			return RejectSettings(Message(fmt.Sprintf("cannot decode settings: %s", err)))
+		}
+		problems = append(problems, settingsErr.Problems...)
+		if settingsErr.undecoded {
+			return RejectSettings(Message(strings.Join(problems, "; ")))
+		}
+	}
+
+	if err = policy.ValidateSettings(settings); err != nil {
+		problems = append(problems, errorMessages(err)...)
+	}
+
+	if len(problems) > 0 {
+		return RejectSettings(Message(strings.Join(problems, "; ")))
+	}
+	return AcceptSettings()
+}
+
+func rejectRequestFromError(err error) ([]byte, error) {
+	var rejectionErr *RejectionError
+	if errors.As(err, &rejectionErr) {
+		return RejectRequest(rejectionErr.Message, rejectionErr.Code)
+	}
+
+	return RejectRequest(Message(err.Error()), NoCode)
+}
@@ -0,0 +1,223 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonschema"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+type testPolicySettings struct {
+	ForbiddenName string `json:"forbiddenName"`
+}
+
+type testPolicy struct{}
+
+func (p *testPolicy) Validate(request protocol.ValidationRequest, settings testPolicySettings) ([]byte, error) {
+	switch request.Request.Name {
+	case settings.ForbiddenName:
+		return nil, NewRejectionError("name is forbidden", 403)
+	case "broken":
+		return nil, errors.New("something went wrong")
+	default:
+		return AcceptRequest()
+	}
+}
+
+func (p *testPolicy) ValidateSettings(settings testPolicySettings) error {
+	if settings.ForbiddenName == "" {
+		return errors.New("forbiddenName must be set")
+	}
+	return nil
+}
+
+func buildPolicyPayload(t *testing.T, name string, settings string) []byte {
+	t.Helper()
+
+	payload, err := json.Marshal(protocol.ValidationRequest{
+		Request: protocol.KubernetesAdmissionRequest{
+			Name: name,
+		},
+		Settings: json.RawMessage(settings),
+	})
+	if err != nil {
+		t.Fatalf("cannot build payload: %v", err)
+	}
+	return payload
+}
+
+func TestValidatePayload(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		payload          []byte
+		expectedAccepted bool
+		expectedMessage  string
+		expectedCode     uint16
+	}{
+		"Accepted": {
+			payload:          buildPolicyPayload(t, "nginx", `{"forbiddenName":"evil"}`),
+			expectedAccepted: true,
+		},
+		"RejectedWithRejectionError": {
+			payload:         buildPolicyPayload(t, "evil", `{"forbiddenName":"evil"}`),
+			expectedMessage: "name is forbidden",
+			expectedCode:    403,
+		},
+		"RejectedWithGenericError": {
+			payload:         buildPolicyPayload(t, "broken", `{"forbiddenName":"evil"}`),
+			expectedMessage: "something went wrong",
+		},
+		"MissingSettings": {
+			payload:          buildPolicyPayload(t, "nginx", `null`),
+			expectedAccepted: true,
+		},
+		"InvalidSettings": {
+			payload:         buildPolicyPayload(t, "nginx", `{"forbiddenName":1}`),
+			expectedMessage: "cannot decode settings: ",
+			expectedCode:    uint16(DecodingErrorCode),
+		},
+		"InvalidPayload": {
+			payload:         []byte(`not json`),
+			expectedMessage: "cannot decode validation request: ",
+			expectedCode:    uint16(DecodingErrorCode),
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			rawResponse, err := ValidatePayload[testPolicySettings](&testPolicy{}, testCase.payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response := protocol.ValidationResponse{}
+			if err = json.Unmarshal(rawResponse, &response); err != nil {
+				t.Fatalf("cannot decode response: %v", err)
+			}
+
+			if response.Accepted != testCase.expectedAccepted {
+				t.Fatalf("expected accepted to be %t, got %t", testCase.expectedAccepted, response.Accepted)
+			}
+			if testCase.expectedAccepted {
+				return
+			}
+
+			if response.Message == nil || !strings.HasPrefix(*response.Message, testCase.expectedMessage) {
+				t.Fatalf("unexpected message: %v", response.Message)
+			}
+			if testCase.expectedCode == 0 {
+				if response.Code != nil {
+					t.Fatalf("unexpected code: %d", *response.Code)
+				}
+			} else if response.Code == nil || *response.Code != testCase.expectedCode {
+				t.Fatalf("unexpected code: %v", response.Code)
+			}
+		})
+	}
+}
+
+func TestValidateSettingsPayload(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		payload         string
+		expectedValid   bool
+		expectedMessage string
+	}{
+		"Valid": {
+			payload:       `{"forbiddenName":"evil"}`,
+			expectedValid: true,
+		},
+		"RejectedByPolicy": {
+			payload:         `{}`,
+			expectedMessage: "forbiddenName must be set",
+		},
+		"CannotDecode": {
+			payload:         `[]`,
+			expectedMessage: "cannot decode settings: ",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			rawResponse, err := ValidateSettingsPayload[testPolicySettings](&testPolicy{}, []byte(testCase.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response := protocol.SettingsValidationResponse{}
+			if err = json.Unmarshal(rawResponse, &response); err != nil {
+				t.Fatalf("cannot decode response: %v", err)
+			}
+
+			if response.Valid != testCase.expectedValid {
+				t.Fatalf("expected valid to be %t, got %t", testCase.expectedValid, response.Valid)
+			}
+			if testCase.expectedValid {
+				return
+			}
+			if response.Message == nil || !strings.HasPrefix(*response.Message, testCase.expectedMessage) {
+				t.Fatalf("unexpected message: %v", response.Message)
+			}
+		})
+	}
+}
+
+type validatedSettingsPolicy struct{}
+
+func (p *validatedSettingsPolicy) Validate(_ protocol.ValidationRequest, _ decodeTestSettings) ([]byte, error) {
+	return AcceptRequest()
+}
+
+func (p *validatedSettingsPolicy) ValidateSettings(settings decodeTestSettings) error {
+	if len(settings.Registries) == 0 {
+		return errors.New("at least one registry must be provided")
+	}
+	return nil
+}
+
+func TestSettingsProblemsAreReportedByBothFunctions(t *testing.T) {
+	settings := `{"replicas": -1, "mode": "enforce", "unknown": true}`
+
+	rawResponse, err := ValidateSettingsPayload[decodeTestSettings](
+		&validatedSettingsPolicy{}, []byte(settings), WithStrictSettings())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settingsResponse := protocol.SettingsValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &settingsResponse); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+	expectedMessage := `unknown field "unknown"; replicas must be positive; at least one registry must be provided`
+	if settingsResponse.Valid || settingsResponse.Message == nil || *settingsResponse.Message != expectedMessage {
+		t.Fatalf("unexpected response: %s", rawResponse)
+	}
+
+	rawResponse, err = ValidatePayload[decodeTestSettings](
+		&validatedSettingsPolicy{}, buildPolicyPayload(t, "nginx", settings), WithStrictSettings())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response := protocol.ValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+	expectedMessage = `invalid settings: unknown field "unknown"; replicas must be positive`
+	if response.Accepted || response.Message == nil || *response.Message != expectedMessage {
+		t.Fatalf("unexpected response: %s", rawResponse)
+	}
+}
+
+func TestSettingsSchemaViolationsSkipPolicyValidation(t *testing.T) {
+	schema := jsonschema.MustParse([]byte(`{"type": "object", "required": ["mode"]}`))
+
+	rawResponse, err := ValidateSettingsPayload[decodeTestSettings](
+		&validatedSettingsPolicy{}, []byte(`{}`), WithSettingsSchema(schema))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response := protocol.SettingsValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+	if response.Valid || response.Message == nil || *response.Message != "mode: is required" {
+		t.Fatalf("unexpected response: %s", rawResponse)
+	}
+}
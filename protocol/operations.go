@@ -0,0 +1,87 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Operation is the operation being performed by an admission request.
+type Operation string
+
+const (
+	// OperationCreate is used when an object is created.
+	OperationCreate Operation = "CREATE"
+	// OperationUpdate is used when an object is updated.
+	OperationUpdate Operation = "UPDATE"
+	// OperationDelete is used when an object is deleted.
+	OperationDelete Operation = "DELETE"
+	// OperationConnect is used when connecting to a resource, like
+	// `pods/exec` or `pods/portforward`.
+	OperationConnect Operation = "CONNECT"
+)
+
+var (
+	// ErrNoObject is returned when accessing the object of a request that
+	// doesn't have one, like DELETE requests.
+	ErrNoObject = errors.New("the request has no object")
+	// ErrNoOldObject is returned when accessing the old object of a request
+	// that doesn't have one. Only UPDATE and DELETE requests have it.
+	ErrNoOldObject = errors.New("the request has no old object")
+)
+
+// HasObject tells whether the request carries an object.
+func (r *KubernetesAdmissionRequest) HasObject() bool {
+	return isPresent(r.Object)
+}
+
+// HasOldObject tells whether the request carries an old object.
+func (r *KubernetesAdmissionRequest) HasOldObject() bool {
+	return isPresent(r.OldObject)
+}
+
+// DecodeObject decodes the object of the request into v. An error wrapping
+// ErrNoObject is returned when the request doesn't have an object.
+func (r *KubernetesAdmissionRequest) DecodeObject(v interface{}) error {
+	if !r.HasObject() {
+		return fmt.Errorf("%w (operation %s)", ErrNoObject, r.Operation)
+	}
+	if err := json.Unmarshal(r.Object, v); err != nil {
+		return fmt.Errorf("cannot decode object: %w", err)
+	}
+	return nil
+}
+
+// DecodeOldObject decodes the old object of the request into v. An error
+// wrapping ErrNoOldObject is returned when the request doesn't have an old
+// object.
+func (r *KubernetesAdmissionRequest) DecodeOldObject(v interface{}) error {
+	if !r.HasOldObject() {
+		return fmt.Errorf("%w (operation %s)", ErrNoOldObject, r.Operation)
+	}
+	if err := json.Unmarshal(r.OldObject, v); err != nil {
+		return fmt.Errorf("cannot decode old object: %w", err)
+	}
+	return nil
+}
+
+// ObjectForDelete returns the object the request is about. On DELETE
+// requests Kubernetes sends the object being deleted as the old object, in
+// this case the old object is returned when the request doesn't have an
+// object. An error wrapping ErrNoObject is returned when no object is found.
+func (r *KubernetesAdmissionRequest) ObjectForDelete() (json.RawMessage, error) {
+	if r.HasObject() {
+		return r.Object, nil
+	}
+	if r.Operation == OperationDelete && r.HasOldObject() {
+		return r.OldObject, nil
+	}
+	return nil, fmt.Errorf("%w (operation %s)", ErrNoObject, r.Operation)
+}
+
+// isPresent tells whether the raw JSON value is set and is not null.
+func isPresent(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null"))
+}
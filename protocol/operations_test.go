@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testObject struct {
+	Name string `json:"name"`
+}
+
+func TestDecodeObjects(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		request                 KubernetesAdmissionRequest
+		expectedObject          string
+		expectedObjectError     error
+		expectedOldObject       string
+		expectedOldObjectError  error
+		expectedObjectForDelete string
+	}{
+		"Create": {
+			request: KubernetesAdmissionRequest{
+				Operation: OperationCreate,
+				Object:    json.RawMessage(`{"name": "new"}`),
+			},
+			expectedObject:          "new",
+			expectedOldObjectError:  ErrNoOldObject,
+			expectedObjectForDelete: `{"name": "new"}`,
+		},
+		"Update": {
+			request: KubernetesAdmissionRequest{
+				Operation: OperationUpdate,
+				Object:    json.RawMessage(`{"name": "new"}`),
+				OldObject: json.RawMessage(`{"name": "old"}`),
+			},
+			expectedObject:          "new",
+			expectedOldObject:       "old",
+			expectedObjectForDelete: `{"name": "new"}`,
+		},
+		"Delete": {
+			request: KubernetesAdmissionRequest{
+				Operation: OperationDelete,
+				Object:    json.RawMessage(`null`),
+				OldObject: json.RawMessage(`{"name": "old"}`),
+			},
+			expectedObjectError:     ErrNoObject,
+			expectedOldObject:       "old",
+			expectedObjectForDelete: `{"name": "old"}`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			var object testObject
+			err := testCase.request.DecodeObject(&object)
+			if !errors.Is(err, testCase.expectedObjectError) || object.Name != testCase.expectedObject {
+				t.Fatalf("unexpected object %+v, error: %v", object, err)
+			}
+
+			var oldObject testObject
+			err = testCase.request.DecodeOldObject(&oldObject)
+			if !errors.Is(err, testCase.expectedOldObjectError) || oldObject.Name != testCase.expectedOldObject {
+				t.Fatalf("unexpected old object %+v, error: %v", oldObject, err)
+			}
+
+			objectForDelete, err := testCase.request.ObjectForDelete()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(objectForDelete) != testCase.expectedObjectForDelete {
+				t.Fatalf("unexpected object: %s", objectForDelete)
+			}
+		})
+	}
+}
+
+func TestDecodeObjectErrors(t *testing.T) {
+	request := KubernetesAdmissionRequest{Operation: OperationConnect}
+
+	err := request.DecodeObject(&testObject{})
+	if err == nil || err.Error() != "the request has no object (operation CONNECT)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = request.ObjectForDelete(); !errors.Is(err, ErrNoObject) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request.Object = json.RawMessage(`[]`)
+	err = request.DecodeObject(&testObject{})
+	if err == nil || errors.Is(err, ErrNoObject) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
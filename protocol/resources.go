@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// coreGroupAlias can be used inside of resource rules to refer to the
+	// core API group, whose name is the empty string.
+	coreGroupAlias = "core"
+	// wildcard matches any group, version, resource or subresource.
+	wildcard = "*"
+)
+
+// UnmarshalJSON accepts both the `resource` key sent by Kubernetes and the
+// `kind` key used by the previous versions of this SDK.
+func (gvr *GroupVersionResource) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Group    string `json:"group"`
+		Version  string `json:"version"`
+		Resource string `json:"resource"`
+		Kind     string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	resource := raw.Resource
+	if resource == "" {
+		resource = raw.Kind
+	}
+	*gvr = GroupVersionResource{
+		Group:    raw.Group,
+		Version:  raw.Version,
+		Resource: resource,
+		Kind:     resource,
+	}
+	return nil
+}
+
+// APIVersion returns the value of the `apiVersion` field of the objects of
+// this kind: `version` for the core group, `group/version` otherwise.
+func (gvk GroupVersionKind) APIVersion() string {
+	if gvk.Group == "" {
+		return gvk.Version
+	}
+	return gvk.Group + "/" + gvk.Version
+}
+
+// GVK returns the fully-qualified type of the object being submitted.
+func (r *KubernetesAdmissionRequest) GVK() GroupVersionKind {
+	return r.Kind
+}
+
+// IsSubresource tells whether the request targets a subresource, like
+// `pods/exec` or `deployments/scale`, instead of the main resource.
+func (r *KubernetesAdmissionRequest) IsSubresource() bool {
+	return r.SubResource != ""
+}
+
+// MatchesResource tells whether the resource being requested matches at
+// least one of the rules.
+func (r *KubernetesAdmissionRequest) MatchesResource(rules ...ResourceRule) bool {
+	for _, rule := range rules {
+		if rule.Matches(r.Resource, r.SubResource) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceRule describes a set of resources, using the same semantics of
+// the rules of the Kubernetes webhook configurations.
+type ResourceRule struct {
+	// Group is the API group, the empty string is the core group. `*`
+	// matches all the groups
+	Group string
+	// Version is the API version, `*` matches all the versions
+	Version string
+	// Resource is the name of the resource, `*` matches all the resources
+	Resource string
+	// SubResource is the name of the subresource. The empty string matches
+	// only the main resource, `*` matches both the main resource and all
+	// its subresources
+	SubResource string
+}
+
+// ParseResourceRule parses a rule written as `group/version/resource`,
+// optionally followed by `/subresource`. The core group is written either
+// as `core` or as the empty string, for example `core/v1/pods/exec` or
+// `/v1/pods/exec`. Each part can be `*`:
+//   - `apps/v1/deployments/scale` matches the scale subresource of the
+//     deployments
+//   - `*/*/*` matches all the main resources, but no subresource
+//   - `*/*/*/*` matches all the resources and all their subresources
+//   - `core/v1/pods/*` matches the pods and all their subresources
+//   - `*/*/*/scale` matches the scale subresource of all the resources
+func ParseResourceRule(rule string) (ResourceRule, error) {
+	group, rest, foundVersion := strings.Cut(rule, "/")
+	version, rest, foundResource := strings.Cut(rest, "/")
+	resource, subResource, foundSubResource := strings.Cut(rest, "/")
+	if !foundVersion || !foundResource || strings.Contains(subResource, "/") {
+		return ResourceRule{}, fmt.Errorf(
+			"invalid resource rule %q: expected group/version/resource[/subresource]", rule)
+	}
+	if version == "" || resource == "" || (foundSubResource && subResource == "") {
+		return ResourceRule{}, fmt.Errorf("invalid resource rule %q: empty version, resource or subresource", rule)
+	}
+
+	if group == coreGroupAlias {
+		group = ""
+	}
+	return ResourceRule{
+		Group:       group,
+		Version:     version,
+		Resource:    resource,
+		SubResource: subResource,
+	}, nil
+}
+
+// MustParseResourceRule is like ParseResourceRule, but panics when the
+// rule is not valid. It's meant to be used with constant rules.
+func MustParseResourceRule(rule string) ResourceRule {
+	parsed, err := ParseResourceRule(rule)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Matches tells whether the resource, and the subresource, match the rule.
+func (rule ResourceRule) Matches(resource GroupVersionResource, subResource string) bool {
+	return matchesPart(rule.Group, resource.Group) &&
+		matchesPart(rule.Version, resource.Version) &&
+		matchesPart(rule.Resource, resource.Resource) &&
+		matchesPart(rule.SubResource, subResource)
+}
+
+// String returns the rule written in the format accepted by
+// ParseResourceRule.
+func (rule ResourceRule) String() string {
+	group := rule.Group
+	if group == "" {
+		group = coreGroupAlias
+	}
+	formatted := group + "/" + rule.Version + "/" + rule.Resource
+	if rule.SubResource != "" {
+		formatted += "/" + rule.SubResource
+	}
+	return formatted
+}
+
+func matchesPart(pattern, value string) bool {
+	return pattern == wildcard || pattern == value
+}
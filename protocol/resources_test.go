@@ -0,0 +1,167 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroupVersionResourceUnmarshal(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		payload  string
+		expected GroupVersionResource
+	}{
+		"Resource": {
+			payload:  `{"group": "apps", "version": "v1", "resource": "deployments"}`,
+			expected: GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments", Kind: "deployments"},
+		},
+		"LegacyKind": {
+			payload:  `{"group": "", "version": "v1", "kind": "pods"}`,
+			expected: GroupVersionResource{Version: "v1", Resource: "pods", Kind: "pods"},
+		},
+		"ResourceWins": {
+			payload:  `{"version": "v1", "resource": "pods", "kind": "Pod"}`,
+			expected: GroupVersionResource{Version: "v1", Resource: "pods", Kind: "pods"},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			var gvr GroupVersionResource
+			if err := json.Unmarshal([]byte(testCase.payload), &gvr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expected, gvr); diff != "" {
+				t.Fatalf("unexpected resource:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdmissionRequestResources(t *testing.T) {
+	payload := `{
+		"uid": "1299d386-525b-4032-98ae-1949f69f9cfc",
+		"kind": {"group": "autoscaling", "version": "v1", "kind": "Scale"},
+		"resource": {"group": "apps", "version": "v1", "resource": "deployments"},
+		"subResource": "scale",
+		"requestKind": {"group": "autoscaling", "version": "v1", "kind": "Scale"},
+		"requestResource": {"group": "apps", "version": "v1", "resource": "deployments"},
+		"requestSubResource": "scale",
+		"operation": "UPDATE"
+	}`
+	var request KubernetesAdmissionRequest
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.RequestResource.Resource != "deployments" {
+		t.Fatalf("unexpected request resource: %+v", request.RequestResource)
+	}
+	if !request.IsSubresource() {
+		t.Fatalf("the request should target a subresource")
+	}
+	if apiVersion := request.GVK().APIVersion(); apiVersion != "autoscaling/v1" {
+		t.Fatalf("unexpected apiVersion: %s", apiVersion)
+	}
+	if !request.MatchesResource(MustParseResourceRule("core/v1/pods"), MustParseResourceRule("apps/*/deployments/scale")) {
+		t.Fatalf("the request should match the rules")
+	}
+
+	serialized, err := json.Marshal(request.Resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"group":"apps","version":"v1","resource":"deployments","kind":"deployments"}`
+	if string(serialized) != expected {
+		t.Fatalf("unexpected serialization: %s", serialized)
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	if apiVersion := (GroupVersionKind{Version: "v1", Kind: "Pod"}).APIVersion(); apiVersion != "v1" {
+		t.Fatalf("unexpected apiVersion: %s", apiVersion)
+	}
+	deployment := GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	if apiVersion := deployment.APIVersion(); apiVersion != "apps/v1" {
+		t.Fatalf("unexpected apiVersion: %s", apiVersion)
+	}
+}
+
+func TestParseResourceRule(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		rule          string
+		expected      ResourceRule
+		expectedError string
+	}{
+		"CoreGroup": {
+			rule:     "core/v1/pods/exec",
+			expected: ResourceRule{Version: "v1", Resource: "pods", SubResource: "exec"},
+		},
+		"EmptyCoreGroup": {
+			rule:     "/v1/pods",
+			expected: ResourceRule{Version: "v1", Resource: "pods"},
+		},
+		"Wildcards": {
+			rule:     "*/*/*/scale",
+			expected: ResourceRule{Group: "*", Version: "*", Resource: "*", SubResource: "scale"},
+		},
+		"TooShort": {
+			rule:          "v1/pods",
+			expectedError: `invalid resource rule "v1/pods": expected group/version/resource[/subresource]`,
+		},
+		"TooLong": {
+			rule:          "core/v1/pods/exec/other",
+			expectedError: `invalid resource rule "core/v1/pods/exec/other": expected group/version/resource[/subresource]`,
+		},
+		"EmptySubresource": {
+			rule:          "apps/v1/deployments/",
+			expectedError: `invalid resource rule "apps/v1/deployments/": empty version, resource or subresource`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			rule, err := ParseResourceRule(testCase.rule)
+			if testCase.expectedError != "" {
+				if err == nil || err.Error() != testCase.expectedError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(testCase.expected, rule); diff != "" {
+				t.Fatalf("unexpected rule:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceRuleMatches(t *testing.T) {
+	pods := GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	for description, testCase := range map[string]struct {
+		rule        string
+		resource    GroupVersionResource
+		subResource string
+		expected    bool
+	}{
+		"ExactResource":                 {rule: "core/v1/pods", resource: pods, expected: true},
+		"ResourceDoesntMatchSub":        {rule: "core/v1/pods", resource: pods, subResource: "exec"},
+		"ExactSubresource":              {rule: "core/v1/pods/exec", resource: pods, subResource: "exec", expected: true},
+		"SubresourceDoesntMatchMain":    {rule: "core/v1/pods/exec", resource: pods},
+		"OtherGroup":                    {rule: "apps/v1/pods", resource: pods},
+		"AllMainResources":              {rule: "*/*/*", resource: deployments, expected: true},
+		"AllMainResourcesNoSub":         {rule: "*/*/*", resource: deployments, subResource: "scale"},
+		"AllSubresourcesOfPods":         {rule: "core/v1/pods/*", resource: pods, subResource: "log", expected: true},
+		"AllSubresourcesIncludeMain":    {rule: "core/v1/pods/*", resource: pods, expected: true},
+		"ScaleOfEveryResource":          {rule: "*/*/*/scale", resource: deployments, subResource: "scale", expected: true},
+		"ScaleOfEveryResourceNotStatus": {rule: "*/*/*/scale", resource: deployments, subResource: "status"},
+	} {
+		t.Run(description, func(t *testing.T) {
+			rule := MustParseResourceRule(testCase.rule)
+			if matches := rule.Matches(testCase.resource, testCase.subResource); matches != testCase.expected {
+				t.Fatalf("rule %s: expected %t, got %t", rule, testCase.expected, matches)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package protocol
 
 import (
 	"encoding/json"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
 )
 
 // ValidationResponse represents the response given when validating a request.
@@ -11,10 +13,32 @@ type ValidationResponse struct {
 	Message *string `json:"message,omitempty"`
 	// Optional - ignored if accepted
 	Code *uint16 `json:"code,omitempty"`
+	// Optional - ignored if accepted. Machine-readable details about the
+	// reasons that caused the rejection
+	Causes []*metav1.StatusCause `json:"causes,omitempty"`
 	// Optional - used only by mutating policies
 	MutatedObject interface{} `json:"mutated_object,omitempty"`
+	// Optional - used only by mutating policies, alternative to
+	// MutatedObject. The patch to apply to the object, its format is
+	// defined by PatchType
+	Patch []byte `json:"patch,omitempty"`
+	// Optional - the type of Patch
+	PatchType *PatchType `json:"patch_type,omitempty"`
+	// Optional - warnings shown to the user, both when the request is
+	// accepted and when it's rejected
+	Warnings []string `json:"warnings,omitempty"`
+	// Optional - annotations added to the audit event of the request
+	AuditAnnotations map[string]string `json:"audit_annotations,omitempty"`
 }
 
+// PatchType is the type of the patch carried by a ValidationResponse.
+type PatchType string
+
+const (
+	// PatchTypeJSONPatch is used by RFC 6902 JSON Patch documents.
+	PatchTypeJSONPatch PatchType = "JSONPatch"
+)
+
 // SettingsValidationResponse repreents the response sent by a policy when validating its settings.
 type SettingsValidationResponse struct {
 	Valid bool `json:"valid"`
@@ -95,7 +119,7 @@ type KubernetesAdmissionRequest struct {
 	//
 	// See documentation for the "matchPolicy" field in the webhook
 	// configuration type.
-	RequestResource GroupVersionKind `json:"requestResource"`
+	RequestResource GroupVersionResource `json:"requestResource"`
 
 	// RequestSubResource is the name of the subresource of the original
 	// API request, if any (for example, "status" or "scale") If this is
@@ -116,7 +140,7 @@ type KubernetesAdmissionRequest struct {
 	// Operation is the operation being performed. This may be different
 	// than the operation requested. e.g. a patch can result in either a
 	// CREATE or UPDATE Operation.
-	Operation string `json:"operation"`
+	Operation Operation `json:"operation"`
 
 	// UserInfo is information about the requesting user
 	UserInfo UserInfo `json:"userInfo"`
@@ -165,19 +189,25 @@ type GroupVersionKind struct {
 }
 
 // GroupVersionResource unambiguously identifies a resource.
+//
+// When unmarshalling, both the `resource` key sent by Kubernetes and the
+// `kind` key used by the previous versions of this SDK are accepted, the
+// value is stored inside of both Resource and Kind.
 type GroupVersionResource struct {
-	Group   string `json:"group"`
-	Version string `json:"version"`
-	Kind    string `json:"kind"`
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+
+	// Deprecated: use Resource instead. Kind holds the same value as
+	// Resource, it's kept only for backwards compatibility.
+	Kind string `json:"kind,omitempty"`
 }
 
 // UserInfo holds information about the user who made the request.
 type UserInfo struct {
 	Username string   `json:"username"`
 	Groups   []string `json:"groups"`
-	// Note, the attributes holds the unmarshalled []bytes as found inside
-	// of original JSON object. This can then be parsed using
-	// `json.Unmarshal()` into a proper type that implements the json
-	// interfaces.
-	Extra json.RawMessage `json:"extra,omitempty"`
+	// Extra holds additional information provided by the authenticator,
+	// like the scopes of the credentials
+	Extra map[string][]string `json:"extra,omitempty"`
 }
@@ -0,0 +1,44 @@
+package protocol
+
+import (
+	"slices"
+	"strings"
+)
+
+// serviceAccountPrefix is the prefix of the usernames of the Kubernetes
+// service accounts: `system:serviceaccount:<namespace>:<name>`.
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// InGroup tells whether the user belongs to the group.
+func (u UserInfo) InGroup(group string) bool {
+	return slices.Contains(u.Groups, group)
+}
+
+// IsServiceAccount tells whether the user is a Kubernetes service account.
+func (u UserInfo) IsServiceAccount() bool {
+	_, _, ok := u.ServiceAccount()
+	return ok
+}
+
+// ServiceAccount returns the namespace and the name of the service account
+// making the request. The returned boolean is false when the user is not a
+// service account.
+func (u UserInfo) ServiceAccount() (string, string, bool) {
+	return ParseServiceAccountUsername(u.Username)
+}
+
+// ParseServiceAccountUsername splits a username in the
+// `system:serviceaccount:<namespace>:<name>` form into the namespace and
+// the name of the service account. The returned boolean is false when the
+// username doesn't refer to a service account.
+func ParseServiceAccountUsername(username string) (string, string, bool) {
+	qualifiedName, found := strings.CutPrefix(username, serviceAccountPrefix)
+	if !found {
+		return "", "", false
+	}
+	namespace, name, found := strings.Cut(qualifiedName, ":")
+	if !found || namespace == "" || name == "" || strings.Contains(name, ":") {
+		return "", "", false
+	}
+	return namespace, name, true
+}
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUserInfo(t *testing.T) {
+	payload := `{
+		"username": "system:serviceaccount:kube-system:replicaset-controller",
+		"groups": ["system:serviceaccounts", "system:serviceaccounts:kube-system"],
+		"extra": {"authentication.kubernetes.io/pod-name": ["kube-controller-manager"]}
+	}`
+	var userInfo UserInfo
+	if err := json.Unmarshal([]byte(payload), &userInfo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedExtra := map[string][]string{"authentication.kubernetes.io/pod-name": {"kube-controller-manager"}}
+	if diff := cmp.Diff(expectedExtra, userInfo.Extra); diff != "" {
+		t.Fatalf("unexpected extra:\n%s", diff)
+	}
+	if !userInfo.InGroup("system:serviceaccounts:kube-system") || userInfo.InGroup("system:masters") {
+		t.Fatalf("unexpected groups: %v", userInfo.Groups)
+	}
+	namespace, name, ok := userInfo.ServiceAccount()
+	if !ok || namespace != "kube-system" || name != "replicaset-controller" {
+		t.Fatalf("unexpected service account: %s, %s, %t", namespace, name, ok)
+	}
+}
+
+func TestParseServiceAccountUsername(t *testing.T) {
+	for _, username := range []string{
+		"alice",
+		"system:serviceaccount:default",
+		"system:serviceaccount::default",
+		"system:serviceaccount:default:",
+		"system:serviceaccount:default:a:b",
+	} {
+		if _, _, ok := ParseServiceAccountUsername(username); ok {
+			t.Fatalf("%q should not be a service account", username)
+		}
+	}
+	if !(UserInfo{Username: "system:serviceaccount:default:builder"}).IsServiceAccount() {
+		t.Fatalf("expected a service account")
+	}
+}
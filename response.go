@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonpatch"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// ResponseBuilder can be used inside of the `validate` function to build
+// responses that carry admission warnings or audit annotations.
+//
+// Use `NewAcceptResponse`, `NewRejectResponse`, `NewMutateResponse` or
+// `NewPatchResponse` to create an instance of `ResponseBuilder`:
+//
+//	return sdk.NewAcceptResponse().
+//		WithWarnings("the `foo` annotation is deprecated").
+//		WithAuditAnnotation("policy.example.com/deprecated", "foo").
+//		Build()
+type ResponseBuilder struct {
+	response protocol.ValidationResponse
+}
+
+// NewAcceptResponse starts building a response that accepts the incoming
+// request.
+func NewAcceptResponse() *ResponseBuilder {
+	return &ResponseBuilder{
+		response: protocol.ValidationResponse{
+			Accepted: true,
+		},
+	}
+}
+
+// NewRejectResponse starts building a response that rejects the incoming
+// request
+// * `message`: optional message to show to the user
+// * `code`: optional error code to show to the user.
+func NewRejectResponse(message Message, code Code) *ResponseBuilder {
+	response := protocol.ValidationResponse{
+		Accepted: false,
+	}
+	if message != NoMessage {
+		msg := string(message)
+		response.Message = &msg
+	}
+	if code != NoCode {
+		c := uint16(code)
+		response.Code = &c
+	}
+
+	return &ResponseBuilder{
+		response: response,
+	}
+}
+
+// NewMutateResponse starts building a response that accepts the request
+// and mutates the final object to match the one provided via the
+// `newObject` param.
+func NewMutateResponse(newObject interface{}) *ResponseBuilder {
+	return &ResponseBuilder{
+		response: protocol.ValidationResponse{
+			Accepted:      true,
+			MutatedObject: newObject,
+		},
+	}
+}
+
+// NewPatchResponse starts building a response that accepts the request and
+// mutates the object by applying the given JSON Patch. An empty patch
+// accepts the request without mutating it.
+func NewPatchResponse(patch jsonpatch.Patch) (*ResponseBuilder, error) {
+	if len(patch) == 0 {
+		return NewAcceptResponse(), nil
+	}
+
+	rawPatch, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize patch: %w", err)
+	}
+
+	patchType := protocol.PatchTypeJSONPatch
+	return &ResponseBuilder{
+		response: protocol.ValidationResponse{
+			Accepted:  true,
+			Patch:     rawPatch,
+			PatchType: &patchType,
+		},
+	}, nil
+}
+
+// WithWarnings adds the given admission warnings to the response. The
+// warnings are shown to the user (e.g. by `kubectl`), regardless of the
+// request being accepted or rejected.
+func (b *ResponseBuilder) WithWarnings(warnings ...string) *ResponseBuilder {
+	b.response.Warnings = append(b.response.Warnings, warnings...)
+	return b
+}
+
+// WithAuditAnnotation adds an annotation to the audit event of the
+// request. Setting the same key twice overwrites the previous value.
+func (b *ResponseBuilder) WithAuditAnnotation(key, value string) *ResponseBuilder {
+	if b.response.AuditAnnotations == nil {
+		b.response.AuditAnnotations = make(map[string]string)
+	}
+	b.response.AuditAnnotations[key] = value
+	return b
+}
+
+// Response returns the response built so far.
+func (b *ResponseBuilder) Response() protocol.ValidationResponse {
+	return b.response
+}
+
+// Build serializes the response, the result can be returned by the
+// `validate` function.
+func (b *ResponseBuilder) Build() ([]byte, error) {
+	return json.Marshal(b.response)
+}
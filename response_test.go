@@ -0,0 +1,57 @@
+package sdk
+
+import (
+	"testing"
+)
+
+func TestResponseBuilder(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		builder          *ResponseBuilder
+		expectedResponse string
+	}{
+		"AcceptWithWarnings": {
+			builder:          NewAcceptResponse().WithWarnings("foo is deprecated", "bar is deprecated"),
+			expectedResponse: `{"accepted":true,"warnings":["foo is deprecated","bar is deprecated"]}`,
+		},
+		"AcceptWithAuditAnnotations": {
+			builder: NewAcceptResponse().
+				WithAuditAnnotation("policy.example.com/deprecated", "foo").
+				WithAuditAnnotation("policy.example.com/mode", "soft"),
+			expectedResponse: `{"accepted":true,"audit_annotations":{"policy.example.com/deprecated":"foo","policy.example.com/mode":"soft"}}`,
+		},
+		"RejectWithWarnings": {
+			builder:          NewRejectResponse("not allowed", 400).WithWarnings("foo is deprecated"),
+			expectedResponse: `{"accepted":false,"message":"not allowed","code":400,"warnings":["foo is deprecated"]}`,
+		},
+		"RejectWithoutMessageAndCode": {
+			builder:          NewRejectResponse(NoMessage, NoCode),
+			expectedResponse: `{"accepted":false}`,
+		},
+		"MutateWithAuditAnnotation": {
+			builder:          NewMutateResponse(map[string]string{"foo": "bar"}).WithAuditAnnotation("mutated", "true"),
+			expectedResponse: `{"accepted":true,"mutated_object":{"foo":"bar"},"audit_annotations":{"mutated":"true"}}`,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			rawResponse, err := testCase.builder.Build()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(rawResponse) != testCase.expectedResponse {
+				t.Fatalf("unexpected response: %s", rawResponse)
+			}
+		})
+	}
+}
+
+func TestAcceptRequestWithWarnings(t *testing.T) {
+	rawResponse, err := AcceptRequestWithWarnings("foo is deprecated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedResponse := `{"accepted":true,"warnings":["foo is deprecated"]}`
+	if string(rawResponse) != expectedResponse {
+		t.Fatalf("unexpected response: %s", rawResponse)
+	}
+}
@@ -0,0 +1,338 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonschema"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+)
+
+// Validator can be implemented by the settings of a policy. The `Validate`
+// method is invoked by DecodeSettings once the settings have been decoded
+// and the default values have been applied.
+//
+// Because DecodeSettings is used both when validating a request and when
+// validating the settings, the same checks are done in both places.
+//
+// Multiple problems can be reported by returning an error created with
+// `errors.Join`: each one of them is shown to the user.
+type Validator interface {
+	Validate() error
+}
+
+// SettingsError reports all the problems found while decoding the
+// settings of a policy.
+type SettingsError struct {
+	Problems []string
+
+	// undecoded is true when the problems prevented the settings from
+	// being decoded
+	undecoded bool
+}
+
+func (e *SettingsError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// SettingsOption changes how the settings are decoded by DecodeSettings.
+type SettingsOption func(*settingsOptions)
+
+type settingsOptions struct {
+	strict bool
+	schema *jsonschema.Schema
+}
+
+// WithStrictSettings makes DecodeSettings report the keys of the settings
+// that don't match exactly any field of the settings struct. This catches
+// typos made by the users, which would otherwise be silently ignored.
+func WithStrictSettings() SettingsOption {
+	return func(o *settingsOptions) {
+		o.strict = true
+	}
+}
+
+// WithSettingsSchema makes DecodeSettings validate the settings against the
+// given JSON Schema, before decoding them. The schema can be generated from
+// the settings type using the `cmd/settings-schema` tool and embedded
+// inside of the policy:
+//
+//	//go:embed settings.schema.json
+//	var settingsSchema []byte
+//
+//	sdk.Register[Settings](&MyPolicy{},
+//		sdk.WithSettingsSchema(jsonschema.MustParse(settingsSchema)))
+func WithSettingsSchema(schema *jsonschema.Schema) SettingsOption {
+	return func(o *settingsOptions) {
+		o.schema = schema
+	}
+}
+
+// DecodeSettings decodes the raw settings provided by the user into `S`:
+//   - When the WithSettingsSchema option is given, the settings are
+//     validated against the schema. Missing settings are validated as an
+//     empty object. The violations of the schema are reported as problems,
+//     the settings are not decoded when there are some.
+//   - The settings are unmarshalled using `json.Unmarshal`. Missing settings
+//     are decoded into the zero value of `S`.
+//   - The values of the `default` struct tags are assigned to the fields
+//     whose key is not part of the settings. Defaults are applied to nested
+//     structs too. The tag holds the JSON representation of the value,
+//     strings can be written without quotes: `default:"info"`,
+//     `default:"3"`, `default:"[\"docker.io\"]"`.
+//   - When the WithStrictSettings option is given, unknown keys are
+//     reported as problems. Unlike `json.Unmarshal`, keys must match the
+//     fields exactly: the ones differing only by case are unknown too.
+//   - When `S` implements the Validator interface, its `Validate` method is
+//     invoked.
+//
+// Problems are aggregated into a single SettingsError. The decoded settings
+// are returned together with it. Errors that prevent the settings from
+// being decoded at all, like malformed JSON or values of the wrong type, are
+// returned as they are.
+func DecodeSettings[S any](raw []byte, opts ...SettingsOption) (S, error) {
+	options := settingsOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var settings S
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		raw = []byte("null")
+	}
+
+	if options.schema != nil {
+		if err := validateSettingsSchema(options.schema, raw); err != nil {
+			return settings, err
+		}
+	}
+
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return settings, err
+	}
+
+	problems := []string{}
+	value := reflect.ValueOf(&settings).Elem()
+	if value.Kind() == reflect.Struct {
+		walker := settingsWalker{strict: options.strict}
+		if err := walker.walk(value, raw, rawjson.Path{}); err != nil {
+			return settings, err
+		}
+		problems = append(problems, walker.problems...)
+	}
+
+	if validator, ok := any(&settings).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			problems = append(problems, errorMessages(err)...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return settings, &SettingsError{Problems: problems}
+	}
+	return settings, nil
+}
+
+func validateSettingsSchema(schema *jsonschema.Schema, raw []byte) error {
+	if string(raw) == "null" {
+		raw = []byte("{}")
+	}
+
+	err := schema.Validate(raw)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return &SettingsError{Problems: validationErr.Problems, undecoded: true}
+	}
+	return err
+}
+
+// errorMessages returns the messages of the errors joined with
+// `errors.Join`, or the message of the error itself.
+func errorMessages(err error) []string {
+	switch e := err.(type) { //nolint:errorlint // only the errors returned by Validate are split
+	case *SettingsError:
+		return e.Problems
+	case interface{ Unwrap() []error }:
+		messages := []string{}
+		for _, wrapped := range e.Unwrap() {
+			messages = append(messages, errorMessages(wrapped)...)
+		}
+		return messages
+	default:
+		return []string{err.Error()}
+	}
+}
+
+// settingsWalker visits the fields of the settings struct, applying the
+// default values and looking for unknown keys.
+type settingsWalker struct {
+	strict   bool
+	problems []string
+}
+
+// walk visits the fields of the struct `value`, which has been decoded from
+// `raw`. `raw` is nil when the struct was not part of the settings.
+func (w *settingsWalker) walk(value reflect.Value, raw json.RawMessage, path rawjson.Path) error {
+	members := map[string]json.RawMessage{}
+	if raw != nil && bytes.HasPrefix(raw, []byte("{")) {
+		if err := json.Unmarshal(raw, &members); err != nil {
+			return err
+		}
+	}
+
+	matched := map[string]bool{}
+	if err := w.walkFields(value, members, matched, path); err != nil {
+		return err
+	}
+
+	if w.strict {
+		unknown := []string{}
+		for key := range members {
+			if !matched[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			w.problems = append(w.problems, fmt.Sprintf("unknown field %q", path.Key(key).String()))
+		}
+	}
+	return nil
+}
+
+// walkFields visits the fields of the struct `value`. The fields of the
+// embedded structs are visited as if they were part of `value`, like
+// `encoding/json` does.
+func (w *settingsWalker) walkFields(
+	value reflect.Value,
+	members map[string]json.RawMessage,
+	matched map[string]bool,
+	path rawjson.Path,
+) error {
+	structType := value.Type()
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		name, tagged, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		fieldValue := value.Field(i)
+
+		if field.Anonymous && !tagged && fieldValue.Kind() == reflect.Struct {
+			if err := w.walkFields(fieldValue, members, matched, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		key, found := findMember(members, name)
+		if found {
+			// the keys differing only by case are decoded, but strict
+			// settings report them like the JSON Schema generated by
+			// `cmd/settings-schema` does
+			matched[key] = key == name
+			if err := w.walkValue(fieldValue, members[key], path.Key(name)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := setDefault(field, fieldValue, path.Key(name)); err != nil {
+			return err
+		}
+		if fieldValue.Kind() == reflect.Struct {
+			if err := w.walk(fieldValue, nil, path.Key(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkValue visits the structs contained inside of the given value.
+func (w *settingsWalker) walkValue(value reflect.Value, raw json.RawMessage, path rawjson.Path) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		return w.walk(value, raw, path)
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return w.walkValue(value.Elem(), raw, path)
+	case reflect.Slice:
+		elements := []json.RawMessage{}
+		if !bytes.HasPrefix(raw, []byte("[")) {
+			return nil
+		}
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return err
+		}
+		for i := 0; i < value.Len() && i < len(elements); i++ {
+			if err := w.walkValue(value.Index(i), elements[i], path.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// jsonFieldName returns the key used by `encoding/json` for the field,
+// whether the key has been set with a `json` tag and whether the field is
+// ignored.
+func jsonFieldName(field reflect.StructField) (string, bool, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false, true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name, false, false
+	}
+	return name, true, false
+}
+
+// findMember looks for the member with the given key. Like `encoding/json`,
+// exact matches are preferred over case-insensitive ones.
+func findMember(members map[string]json.RawMessage, name string) (string, bool) {
+	if _, found := members[name]; found {
+		return name, true
+	}
+	for key := range members {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// setDefault assigns the value of the `default` tag to the field.
+func setDefault(field reflect.StructField, value reflect.Value, path rawjson.Path) error {
+	defaultValue, found := field.Tag.Lookup("default")
+	if !found {
+		return nil
+	}
+
+	if value.Kind() == reflect.String {
+		value.SetString(defaultValue)
+		return nil
+	}
+	if err := json.Unmarshal([]byte(defaultValue), value.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid default value of %q: %w", path.String(), err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package sdk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubewarden/policy-sdk-go/pkg/jsonschema"
+)
+
+type registrySettings struct {
+	Name     string   `json:"name"`
+	Insecure bool     `json:"insecure" default:"false"`
+	Mirrors  []string `json:"mirrors" default:"[\"mirror.example.com\"]"`
+}
+
+type loggingSettings struct {
+	Level string `json:"level" default:"info"`
+}
+
+type commonSettings struct {
+	Verbose bool `json:"verbose" default:"true"`
+}
+
+type decodeTestSettings struct {
+	commonSettings
+
+	Replicas   int                `json:"replicas" default:"3"`
+	Mode       string             `json:"mode,omitempty" default:"enforce"`
+	Logging    loggingSettings    `json:"logging"`
+	Registries []registrySettings `json:"registries"`
+	Ignored    string             `json:"-" default:"ignored"`
+}
+
+func (s *decodeTestSettings) Validate() error {
+	errs := []error{}
+	if s.Replicas <= 0 {
+		errs = append(errs, errors.New("replicas must be positive"))
+	}
+	if s.Mode != "enforce" && s.Mode != "monitor" {
+		errs = append(errs, errors.New("mode must be either enforce or monitor"))
+	}
+	return errors.Join(errs...)
+}
+
+func TestDecodeSettings(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		raw              string
+		options          []SettingsOption
+		expectedSettings decodeTestSettings
+		expectedProblems []string
+	}{
+		"Defaults": {
+			raw: ``,
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       3,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "info"},
+			},
+		},
+		"DefaultsAreNotAppliedToProvidedValues": {
+			raw: `{"replicas": 1, "mode": "monitor", "verbose": false, "logging": {"level": "debug"}}`,
+			expectedSettings: decodeTestSettings{
+				Replicas: 1,
+				Mode:     "monitor",
+				Logging:  loggingSettings{Level: "debug"},
+			},
+		},
+		"DefaultsOfNestedStructs": {
+			raw: `{"logging": {}, "registries": [{"name": "docker.io"}, {"name": "quay.io", "mirrors": []}]}`,
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       3,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "info"},
+				Registries: []registrySettings{
+					{Name: "docker.io", Mirrors: []string{"mirror.example.com"}},
+					{Name: "quay.io", Mirrors: []string{}},
+				},
+			},
+		},
+		"CaseInsensitiveKeys": {
+			raw: `{"Replicas": 2}`,
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       2,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "info"},
+			},
+		},
+		"UnknownKeysAreIgnoredByDefault": {
+			raw: `{"replica": 2}`,
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       3,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "info"},
+			},
+		},
+		"StrictMode": {
+			raw:     `{"replica": 2, "verbose": true, "logging": {"lvl": "debug"}, "registries": [{"nme": "docker.io"}], "Ignored": "x"}`,
+			options: []SettingsOption{WithStrictSettings()},
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       3,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "info"},
+				Registries:     []registrySettings{{Mirrors: []string{"mirror.example.com"}}},
+			},
+			expectedProblems: []string{
+				`unknown field "logging.lvl"`,
+				`unknown field "registries[0].nme"`,
+				`unknown field "Ignored"`,
+				`unknown field "replica"`,
+			},
+		},
+		"StrictModeMatchesKeysExactly": {
+			raw:     `{"Replicas": 2, "logging": {"Level": "debug"}}`,
+			options: []SettingsOption{WithStrictSettings()},
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Replicas:       2,
+				Mode:           "enforce",
+				Logging:        loggingSettings{Level: "debug"},
+			},
+			expectedProblems: []string{
+				`unknown field "logging.Level"`,
+				`unknown field "Replicas"`,
+			},
+		},
+		"ValidationProblemsAreAggregated": {
+			raw:     `{"replicas": 0, "mode": "audit", "foo": 1}`,
+			options: []SettingsOption{WithStrictSettings()},
+			expectedSettings: decodeTestSettings{
+				commonSettings: commonSettings{Verbose: true},
+				Mode:           "audit",
+				Logging:        loggingSettings{Level: "info"},
+			},
+			expectedProblems: []string{
+				`unknown field "foo"`,
+				"replicas must be positive",
+				"mode must be either enforce or monitor",
+			},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			settings, err := DecodeSettings[decodeTestSettings]([]byte(testCase.raw), testCase.options...)
+
+			var problems []string
+			var settingsErr *SettingsError
+			if errors.As(err, &settingsErr) {
+				problems = settingsErr.Problems
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(testCase.expectedProblems, problems); diff != "" {
+				t.Fatalf("unexpected problems:\n%s", diff)
+			}
+			if diff := cmp.Diff(testCase.expectedSettings, settings, cmp.AllowUnexported(decodeTestSettings{})); diff != "" {
+				t.Fatalf("unexpected settings:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeSettingsErrors(t *testing.T) {
+	if _, err := DecodeSettings[decodeTestSettings]([]byte(`{"replicas": "three"}`)); err == nil {
+		t.Fatalf("expected a decoding error")
+	}
+
+	type invalidDefault struct {
+		Replicas int `json:"replicas" default:"three"`
+	}
+	_, err := DecodeSettings[invalidDefault]([]byte(`{}`))
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid default value of "replicas": `) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSettingsErrorMessage(t *testing.T) {
+	err := &SettingsError{Problems: []string{"a is required", "b must be positive"}}
+	if err.Error() != "a is required; b must be positive" {
+		t.Fatalf("unexpected message: %s", err.Error())
+	}
+}
+
+func TestDecodeSettingsWithSchema(t *testing.T) {
+	schema := jsonschema.MustParse([]byte(`{
+		"type": "object",
+		"properties": {"replicas": {"type": "integer", "minimum": 1}, "mode": {"type": "string"}},
+		"required": ["mode"]
+	}`))
+
+	for description, testCase := range map[string]struct {
+		raw              string
+		expectedProblems []string
+		expectedReplicas int
+	}{
+		"Valid": {
+			raw:              `{"mode": "monitor", "replicas": 2}`,
+			expectedReplicas: 2,
+		},
+		"MissingSettings": {
+			raw:              ``,
+			expectedProblems: []string{"mode: is required"},
+		},
+		"SchemaViolations": {
+			raw:              `{"replicas": "two"}`,
+			expectedProblems: []string{"mode: is required", "replicas: must be of type integer, got string"},
+		},
+		"SchemaIsCheckedBeforeValidator": {
+			raw:              `{"mode": "audit", "replicas": 0}`,
+			expectedProblems: []string{"replicas: must be greater than or equal to 1"},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			settings, err := DecodeSettings[decodeTestSettings]([]byte(testCase.raw), WithSettingsSchema(schema))
+
+			var problems []string
+			var settingsErr *SettingsError
+			if errors.As(err, &settingsErr) {
+				problems = settingsErr.Problems
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(testCase.expectedProblems, problems); diff != "" {
+				t.Fatalf("unexpected problems:\n%s", diff)
+			}
+			if settings.Replicas != testCase.expectedReplicas {
+				t.Fatalf("unexpected replicas: %d", settings.Replicas)
+			}
+		})
+	}
+}
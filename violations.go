@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// CauseType is a machine-readable description of the cause of a violation.
+// The values match the ones used by Kubernetes inside of `StatusCause`
+// objects.
+type CauseType string
+
+const (
+	// CauseTypeFieldValueNotFound is used when a referenced value cannot
+	// be found.
+	CauseTypeFieldValueNotFound CauseType = "FieldValueNotFound"
+	// CauseTypeFieldValueRequired is used when a required value is missing.
+	CauseTypeFieldValueRequired CauseType = "FieldValueRequired"
+	// CauseTypeFieldValueDuplicate is used when a value must be unique but
+	// it's not.
+	CauseTypeFieldValueDuplicate CauseType = "FieldValueDuplicate"
+	// CauseTypeFieldValueInvalid is used when a value is not valid.
+	CauseTypeFieldValueInvalid CauseType = "FieldValueInvalid"
+	// CauseTypeFieldValueNotSupported is used when a value is not one of
+	// the supported ones.
+	CauseTypeFieldValueNotSupported CauseType = "FieldValueNotSupported"
+	// CauseTypeFieldValueForbidden is used when a value is valid, but it's
+	// not allowed by the policy.
+	CauseTypeFieldValueForbidden CauseType = "FieldValueForbidden"
+)
+
+// Violation describes a single reason why a request is rejected.
+type Violation struct {
+	// Field is the path of the field that caused the violation, e.g.
+	// `spec.containers[0].image`. Optional.
+	Field string
+	// Message is a human-readable description of the violation.
+	Message string
+	// Reason is a machine-readable description of the violation. Optional.
+	Reason CauseType
+}
+
+// String returns the violation in the `<field>: <message>` form.
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// ViolationSet collects all the violations found while evaluating a
+// request, so that all of them can be reported to the user at once.
+//
+// The zero value is an empty set ready to be used.
+type ViolationSet struct {
+	violations []Violation
+}
+
+// Add adds a new violation to the set
+// * `field`: optional path of the field that caused the violation
+// * `reason`: optional machine-readable reason of the violation
+// * `message`: description of the violation.
+func (s *ViolationSet) Add(field string, reason CauseType, message string) {
+	s.violations = append(s.violations, Violation{
+		Field:   field,
+		Message: message,
+		Reason:  reason,
+	})
+}
+
+// Addf is like `Add`, but the message is built using `fmt.Sprintf`.
+func (s *ViolationSet) Addf(field string, reason CauseType, format string, args ...interface{}) {
+	s.Add(field, reason, fmt.Sprintf(format, args...))
+}
+
+// Len returns the number of violations inside of the set.
+func (s *ViolationSet) Len() int {
+	return len(s.violations)
+}
+
+// IsEmpty returns true when the set doesn't have any violation.
+func (s *ViolationSet) IsEmpty() bool {
+	return len(s.violations) == 0
+}
+
+// Violations returns the violations inside of the set, in the order they
+// have been added.
+func (s *ViolationSet) Violations() []Violation {
+	return s.violations
+}
+
+// Message returns a human-readable summary of all the violations.
+func (s *ViolationSet) Message() string {
+	switch len(s.violations) {
+	case 0:
+		return ""
+	case 1:
+		return s.violations[0].String()
+	default:
+		messages := make([]string, 0, len(s.violations))
+		for _, violation := range s.violations {
+			messages = append(messages, violation.String())
+		}
+		return fmt.Sprintf("%d violations found: %s", len(s.violations), strings.Join(messages, "; "))
+	}
+}
+
+// Causes returns the violations as Kubernetes `StatusCause` objects.
+func (s *ViolationSet) Causes() []*metav1.StatusCause {
+	causes := make([]*metav1.StatusCause, 0, len(s.violations))
+	for _, violation := range s.violations {
+		causes = append(causes, &metav1.StatusCause{
+			Field:   violation.Field,
+			Message: violation.Message,
+			Reason:  string(violation.Reason),
+		})
+	}
+	return causes
+}
+
+// ValidationResponse builds the response for the violations inside of the
+// set. The request is accepted when the set is empty, otherwise it's
+// rejected with a summary message, the given error code and one cause per
+// violation.
+// * `code`: optional error code to show to the user.
+func (s *ViolationSet) ValidationResponse(code Code) protocol.ValidationResponse {
+	if s.IsEmpty() {
+		return protocol.ValidationResponse{
+			Accepted: true,
+		}
+	}
+
+	response := NewRejectResponse(Message(s.Message()), code).Response()
+	response.Causes = s.Causes()
+	return response
+}
+
+// Response can be used inside of the `validate` function to accept the
+// incoming request when the set is empty, or to reject it reporting all the
+// violations otherwise. See `ValidationResponse`.
+func (s *ViolationSet) Response(code Code) ([]byte, error) {
+	return json.Marshal(s.ValidationResponse(code))
+}
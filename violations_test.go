@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+func TestViolationSetEmpty(t *testing.T) {
+	violations := ViolationSet{}
+
+	rawResponse, err := violations.Response(400)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(rawResponse) != `{"accepted":true}` {
+		t.Fatalf("unexpected response: %s", rawResponse)
+	}
+}
+
+func TestViolationSetMessage(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		violations      []Violation
+		expectedMessage string
+	}{
+		"SingleViolation": {
+			violations: []Violation{
+				{Field: "spec.containers[0].image", Message: "image is not allowed"},
+			},
+			expectedMessage: "spec.containers[0].image: image is not allowed",
+		},
+		"SingleViolationWithoutField": {
+			violations: []Violation{
+				{Message: "object is not allowed"},
+			},
+			expectedMessage: "object is not allowed",
+		},
+		"MultipleViolations": {
+			violations: []Violation{
+				{Field: "spec.containers[0].image", Message: "image is not allowed"},
+				{Field: "spec.containers[1].image", Message: "image is not allowed"},
+				{Message: "too many containers"},
+			},
+			expectedMessage: "3 violations found: spec.containers[0].image: image is not allowed; " +
+				"spec.containers[1].image: image is not allowed; too many containers",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			violations := ViolationSet{}
+			for _, violation := range testCase.violations {
+				violations.Add(violation.Field, violation.Reason, violation.Message)
+			}
+
+			if violations.Len() != len(testCase.violations) {
+				t.Fatalf("expected %d violations, got %d", len(testCase.violations), violations.Len())
+			}
+			if message := violations.Message(); message != testCase.expectedMessage {
+				t.Fatalf("unexpected message: %q", message)
+			}
+		})
+	}
+}
+
+func TestViolationSetResponse(t *testing.T) {
+	violations := ViolationSet{}
+	violations.Add("spec.containers[0].image", CauseTypeFieldValueForbidden, "image is not allowed")
+	violations.Addf("spec.initContainers[0].image", CauseTypeFieldValueForbidden, "image %q is not allowed", "busybox")
+
+	rawResponse, err := violations.Response(403)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response := protocol.ValidationResponse{}
+	if err = json.Unmarshal(rawResponse, &response); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+
+	if response.Accepted {
+		t.Fatal("expected the request to be rejected")
+	}
+	if response.Code == nil || *response.Code != 403 {
+		t.Fatalf("unexpected code: %v", response.Code)
+	}
+	expectedMessage := `2 violations found: spec.containers[0].image: image is not allowed; ` +
+		`spec.initContainers[0].image: image "busybox" is not allowed`
+	if response.Message == nil || *response.Message != expectedMessage {
+		t.Fatalf("unexpected message: %v", response.Message)
+	}
+
+	expectedCauses := []*metav1.StatusCause{
+		{
+			Field:   "spec.containers[0].image",
+			Message: "image is not allowed",
+			Reason:  "FieldValueForbidden",
+		},
+		{
+			Field:   "spec.initContainers[0].image",
+			Message: `image "busybox" is not allowed`,
+			Reason:  "FieldValueForbidden",
+		},
+	}
+	if diff := cmp.Diff(expectedCauses, response.Causes); diff != "" {
+		t.Fatalf("unexpected causes:\n%s", diff)
+	}
+}
@@ -0,0 +1,319 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// WorkloadKind describes a kind of object that embeds a pod template, like
+// Deployments, Jobs or Pods themselves.
+type WorkloadKind struct {
+	// Group is the API group of the kind. An empty group matches objects
+	// of any API group, unless another kind with the same name has been
+	// registered for the exact group of the object.
+	Group string
+	// Kind is the name of the kind, e.g. `Deployment`
+	Kind string
+	// PodTemplatePath is the path of the pod template inside of the object,
+	// e.g. `spec.template`. The pod template is the object holding the
+	// `metadata` and the `spec` of the pods. The empty path is used by
+	// Pods, which are their own template.
+	PodTemplatePath rawjson.Path
+
+	// apiVersion is the canonical API version of the kinds known by the
+	// SDK, used only to describe them: they match objects of any group and
+	// version
+	apiVersion string
+}
+
+// workloadKinds holds the kinds known by the SDK. The order of the slice is
+// the one used when reporting the supported kinds to the user.
+//
+//nolint:gochecknoglobals // kinds can be registered by the policy at runtime
+var workloadKinds = []WorkloadKind{
+	{Kind: "Deployment", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "apps/v1"},
+	{Kind: "ReplicaSet", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "apps/v1"},
+	{Kind: "StatefulSet", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "apps/v1"},
+	{Kind: "DaemonSet", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "apps/v1"},
+	{Kind: "ReplicationController", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "v1"},
+	{Kind: "Job", PodTemplatePath: rawjson.MustParsePath("spec.template"), apiVersion: "batch/v1"},
+	{Kind: "CronJob", PodTemplatePath: rawjson.MustParsePath("spec.jobTemplate.spec.template"), apiVersion: "batch/v1"},
+	{Kind: "Pod", PodTemplatePath: rawjson.Path{}, apiVersion: "v1"},
+}
+
+// String returns the kind together with its API group and version, like
+// `apps/v1 Deployment`. The kinds registered by the policies match any
+// version, written as `*`, and their empty group matches any group.
+func (k WorkloadKind) String() string {
+	if k.apiVersion != "" {
+		return k.apiVersion + " " + k.Kind
+	}
+	group := k.Group
+	if group == "" {
+		group = "*"
+	}
+	return group + "/* " + k.Kind
+}
+
+// RegisterWorkloadKind makes the SDK aware of a kind that embeds a pod
+// template, for example a Custom Resource like an Argo Rollout:
+//
+//	sdk.RegisterWorkloadKind(sdk.WorkloadKind{
+//		Group:           "argoproj.io",
+//		Kind:            "Rollout",
+//		PodTemplatePath: rawjson.MustParsePath("spec.template"),
+//	})
+//
+// Registering a kind that is already known replaces its definition.
+func RegisterWorkloadKind(kind WorkloadKind) error {
+	if kind.Kind == "" {
+		return errors.New("the kind of the workload cannot be empty")
+	}
+
+	for i, known := range workloadKinds {
+		if known.Group == kind.Group && known.Kind == kind.Kind {
+			workloadKinds[i] = kind
+			return nil
+		}
+	}
+	workloadKinds = append(workloadKinds, kind)
+	return nil
+}
+
+// lookupWorkloadKind returns the definition of the given kind. Definitions
+// registered for the exact group of the kind take precedence over the ones
+// matching any group.
+func lookupWorkloadKind(gvk protocol.GroupVersionKind) (WorkloadKind, bool) {
+	var fallback *WorkloadKind
+	for i := range workloadKinds {
+		known := &workloadKinds[i]
+		if known.Kind != gvk.Kind {
+			continue
+		}
+		if known.Group != "" && known.Group == gvk.Group {
+			return *known, true
+		}
+		if known.Group == "" && fallback == nil {
+			fallback = known
+		}
+	}
+	if fallback == nil {
+		return WorkloadKind{}, false
+	}
+	return *fallback, true
+}
+
+// ErrMissingPodSpec is returned when the object doesn't have a PodSpec, for
+// example a Deployment without `spec` or a CronJob without `jobTemplate`.
+var ErrMissingPodSpec = errors.New("missing PodSpec")
+
+// WorkloadOption changes the behavior of a Workload.
+type WorkloadOption func(*Workload)
+
+// WithMissingPodSpecAsEmpty makes the Workload treat a missing PodSpec as an
+// empty one, instead of returning ErrMissingPodSpec.
+func WithMissingPodSpecAsEmpty() WorkloadOption {
+	return func(w *Workload) {
+		w.missingPodSpecAsEmpty = true
+	}
+}
+
+// UnsupportedWorkloadKindError is returned when the object doesn't embed a
+// pod template.
+type UnsupportedWorkloadKindError struct {
+	// Kind is the kind of the object
+	Kind string
+	// GroupVersionKind is the API group, version and kind of the object
+	GroupVersionKind protocol.GroupVersionKind
+}
+
+func (e *UnsupportedWorkloadKindError) Error() string {
+	return fmt.Sprintf("object of kind %s should be one of these kinds: %s",
+		formatGroupVersionKind(e.GroupVersionKind), supportedWorkloadKinds())
+}
+
+// formatGroupVersionKind returns the kind of an object like
+// `batch/v1 Job`, or `v1 Pod` for the core group.
+func formatGroupVersionKind(gvk protocol.GroupVersionKind) string {
+	apiVersion := gvk.Version
+	if gvk.Group != "" {
+		apiVersion = gvk.Group + "/" + gvk.Version
+	}
+	if apiVersion == "" {
+		return gvk.Kind
+	}
+	return apiVersion + " " + gvk.Kind
+}
+
+func supportedWorkloadKinds() string {
+	kinds := make([]string, 0, len(workloadKinds))
+	for _, kind := range workloadKinds {
+		kinds = append(kinds, kind.String())
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// supportedWorkloadKindNames returns the names of the supported kinds, as
+// reported by MutatePodSpecFromRequest.
+func supportedWorkloadKindNames() string {
+	kinds := make([]string, 0, len(workloadKinds))
+	for _, kind := range workloadKinds {
+		kinds = append(kinds, kind.Kind)
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// Workload gives access to the pod template embedded inside of an object.
+// The raw JSON of the object is changed in place: the fields that are not
+// known by the Kubernetes Go types are preserved.
+type Workload struct {
+	kind                  WorkloadKind
+	object                []byte
+	missingPodSpecAsEmpty bool
+}
+
+// NewWorkload returns the Workload of the object of the given request. It
+// returns an UnsupportedWorkloadKindError when the kind of the object
+// doesn't embed a pod template.
+func NewWorkload(validationRequest protocol.ValidationRequest, opts ...WorkloadOption) (*Workload, error) {
+	return NewWorkloadFromObject(validationRequest.Request.Kind, validationRequest.Request.Object, opts...)
+}
+
+// NewWorkloadFromObject returns the Workload of the given raw object. It
+// returns an UnsupportedWorkloadKindError when the kind of the object
+// doesn't embed a pod template.
+func NewWorkloadFromObject(gvk protocol.GroupVersionKind, object []byte, opts ...WorkloadOption) (*Workload, error) {
+	kind, found := lookupWorkloadKind(gvk)
+	if !found {
+		return nil, &UnsupportedWorkloadKindError{Kind: gvk.Kind, GroupVersionKind: gvk}
+	}
+
+	workload := &Workload{kind: kind, object: object}
+	for _, opt := range opts {
+		opt(workload)
+	}
+	return workload, nil
+}
+
+// Kind returns the definition of the kind of the object.
+func (w *Workload) Kind() WorkloadKind {
+	return w.kind
+}
+
+// Object returns the raw JSON of the object, including the changes done
+// so far.
+func (w *Workload) Object() []byte {
+	return w.object
+}
+
+// Metadata returns the metadata of the object.
+func (w *Workload) Metadata() (metav1.ObjectMeta, error) {
+	metadata := metav1.ObjectMeta{}
+	err := w.decode(rawjson.Path{}.Key("metadata"), &metadata)
+	return metadata, err
+}
+
+// PodTemplateMetadataPath returns the path of the metadata of the pod
+// template.
+func (w *Workload) PodTemplateMetadataPath() rawjson.Path {
+	return w.kind.PodTemplatePath.Key("metadata")
+}
+
+// PodTemplateMetadata returns the metadata of the pod template, which
+// holds the labels and the annotations given to the pods. For Pods, this is
+// the metadata of the object.
+func (w *Workload) PodTemplateMetadata() (metav1.ObjectMeta, error) {
+	metadata := metav1.ObjectMeta{}
+	err := w.decode(w.PodTemplateMetadataPath(), &metadata)
+	return metadata, err
+}
+
+// PodSpecPath returns the path of the PodSpec inside of the object, e.g.
+// `spec.template.spec`. It can be used to build the path of the values to
+// change with the `rawjson` package.
+func (w *Workload) PodSpecPath() rawjson.Path {
+	return w.kind.PodTemplatePath.Key("spec")
+}
+
+// PodSpec returns the PodSpec of the object. It returns ErrMissingPodSpec
+// when the object doesn't have one, unless the Workload has been created
+// using WithMissingPodSpecAsEmpty.
+func (w *Workload) PodSpec() (corev1.PodSpec, error) {
+	if err := w.checkPodSpec(); err != nil {
+		return corev1.PodSpec{}, err
+	}
+
+	podSpec := corev1.PodSpec{}
+	err := w.decode(w.PodSpecPath(), &podSpec)
+	return podSpec, err
+}
+
+// SetPodSpec replaces the PodSpec of the object. The rest of the object is
+// left untouched. It returns ErrMissingPodSpec when the object doesn't have
+// a PodSpec, unless the Workload has been created using
+// WithMissingPodSpecAsEmpty: in that case the PodSpec is added.
+func (w *Workload) SetPodSpec(podSpec corev1.PodSpec) error {
+	if err := w.checkPodSpec(); err != nil {
+		return err
+	}
+	return w.set(w.PodSpecPath(), podSpec)
+}
+
+// checkPodSpec returns ErrMissingPodSpec when the object doesn't have a
+// PodSpec and missing PodSpecs must not be treated as empty ones.
+func (w *Workload) checkPodSpec() error {
+	if w.missingPodSpecAsEmpty {
+		return nil
+	}
+
+	raw, found, err := rawjson.Get(w.object, w.PodSpecPath())
+	if err != nil {
+		return fmt.Errorf("cannot read %s of %s: %w", w.PodSpecPath(), w.kind.Kind, err)
+	}
+	if !found || string(raw) == "null" {
+		return fmt.Errorf("%w: %s doesn't have %s", ErrMissingPodSpec, w.kind.Kind, w.PodSpecPath())
+	}
+	return nil
+}
+
+// SetPodTemplateMetadata replaces the metadata of the pod template. The
+// rest of the object is left untouched.
+func (w *Workload) SetPodTemplateMetadata(metadata metav1.ObjectMeta) error {
+	return w.set(w.PodTemplateMetadataPath(), metadata)
+}
+
+// Mutate accepts the request and mutates the final object to match the
+// object of the Workload.
+func (w *Workload) Mutate() ([]byte, error) {
+	return MutateRawObject(w.object)
+}
+
+func (w *Workload) decode(path rawjson.Path, value interface{}) error {
+	raw, found, err := rawjson.Get(w.object, path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s of %s: %w", path, w.kind.Kind, err)
+	}
+	if !found {
+		return nil
+	}
+	if err = json.Unmarshal(raw, value); err != nil {
+		return fmt.Errorf("cannot decode %s of %s: %w", path, w.kind.Kind, err)
+	}
+	return nil
+}
+
+func (w *Workload) set(path rawjson.Path, value interface{}) error {
+	object, err := rawjson.Set(w.object, path, value)
+	if err != nil {
+		return fmt.Errorf("cannot set %s of %s: %w", path, w.kind.Kind, err)
+	}
+	w.object = object
+	return nil
+}
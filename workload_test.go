@@ -0,0 +1,273 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/rawjson"
+	"github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// restoreWorkloadKinds undoes the registrations done by a test.
+func restoreWorkloadKinds(t *testing.T) {
+	saved := append([]WorkloadKind{}, workloadKinds...)
+	t.Cleanup(func() {
+		workloadKinds = saved
+	})
+}
+
+func TestWorkloadPodSpec(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		kind            string
+		object          string
+		expectedPath    string
+		expectedAccount string
+	}{
+		"Deployment": {
+			kind:            "Deployment",
+			object:          `{"spec": {"template": {"spec": {"serviceAccountName": "deployment"}}}}`,
+			expectedPath:    "spec.template.spec",
+			expectedAccount: "deployment",
+		},
+		"CronJob": {
+			kind:            "CronJob",
+			object:          `{"spec": {"jobTemplate": {"spec": {"template": {"spec": {"serviceAccountName": "cronjob"}}}}}}`,
+			expectedPath:    "spec.jobTemplate.spec.template.spec",
+			expectedAccount: "cronjob",
+		},
+		"Pod": {
+			kind:            "Pod",
+			object:          `{"spec": {"serviceAccountName": "pod"}}`,
+			expectedPath:    "spec",
+			expectedAccount: "pod",
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			workload, err := NewWorkloadFromObject(protocol.GroupVersionKind{Kind: testCase.kind}, []byte(testCase.object))
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+
+			if workload.PodSpecPath().String() != testCase.expectedPath {
+				t.Fatalf("Unexpected PodSpec path: %s", workload.PodSpecPath())
+			}
+
+			podSpec, err := workload.PodSpec()
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if podSpec.ServiceAccountName != testCase.expectedAccount {
+				t.Fatalf("Unexpected PodSpec: %+v", podSpec)
+			}
+		})
+	}
+}
+
+func TestMissingPodSpec(t *testing.T) {
+	for description, testCase := range map[string]struct {
+		kind   string
+		object string
+	}{
+		"DeploymentWithoutSpec":            {kind: "Deployment", object: `{"kind": "Deployment"}`},
+		"ReplicaSetWithoutTemplate":        {kind: "ReplicaSet", object: `{"spec": {}}`},
+		"StatefulSetWithNullTemplate":      {kind: "StatefulSet", object: `{"spec": {"template": null}}`},
+		"DaemonSetWithoutPodSpec":          {kind: "DaemonSet", object: `{"spec": {"template": {"metadata": {}}}}`},
+		"ReplicationControllerWithNullPod": {kind: "ReplicationController", object: `{"spec": {"template": {"spec": null}}}`},
+		"JobWithNullSpec":                  {kind: "Job", object: `{"spec": null}`},
+		"CronJobWithoutJobTemplate":        {kind: "CronJob", object: `{"spec": {"schedule": "* * * * *"}}`},
+		"PodWithoutSpec":                   {kind: "Pod", object: `{"metadata": {"name": "nginx"}}`},
+	} {
+		t.Run(description, func(t *testing.T) {
+			validationRequest := protocol.ValidationRequest{
+				Request: protocol.KubernetesAdmissionRequest{
+					Kind:   protocol.GroupVersionKind{Kind: testCase.kind},
+					Object: json.RawMessage(testCase.object),
+				},
+			}
+
+			if _, err := ExtractPodSpecFromObject(validationRequest); !errors.Is(err, ErrMissingPodSpec) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := MutatePodSpecFromRequest(validationRequest, corev1.PodSpec{}); !errors.Is(err, ErrMissingPodSpec) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			podSpec, err := ExtractPodSpecFromObject(validationRequest, WithMissingPodSpecAsEmpty())
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if podSpec.ServiceAccountName != "" || len(podSpec.Containers) != 0 {
+				t.Fatalf("PodSpec is not empty: %+v", podSpec)
+			}
+
+			rawResponse, err := MutatePodSpecFromRequest(
+				validationRequest,
+				corev1.PodSpec{ServiceAccountName: "nginx"},
+				WithMissingPodSpecAsEmpty())
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			response := protocol.ValidationResponse{MutatedObject: &json.RawMessage{}}
+			if err = json.Unmarshal(rawResponse, &response); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			workload, err := NewWorkloadFromObject(
+				protocol.GroupVersionKind{Kind: testCase.kind},
+				*response.MutatedObject.(*json.RawMessage))
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			podSpec, err = workload.PodSpec()
+			if err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+			if podSpec.ServiceAccountName != "nginx" {
+				t.Fatalf("PodSpec not added: %s", workload.Object())
+			}
+		})
+	}
+}
+
+func TestWorkloadMetadata(t *testing.T) {
+	object := `{
+		"metadata": {"name": "nginx", "labels": {"app": "owner"}},
+		"spec": {"template": {"metadata": {"labels": {"app": "nginx"}}, "spec": {}}}
+	}`
+	workload, err := NewWorkloadFromObject(protocol.GroupVersionKind{Group: "apps", Kind: "Deployment"}, []byte(object))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	metadata, err := workload.Metadata()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if metadata.Name != "nginx" || metadata.Labels["app"] != "owner" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+
+	templateMetadata, err := workload.PodTemplateMetadata()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if templateMetadata.Labels["app"] != "nginx" {
+		t.Fatalf("Unexpected pod template metadata: %+v", templateMetadata)
+	}
+}
+
+func TestWorkloadSetPreservesUnknownFields(t *testing.T) {
+	object := `{"kind": "Deployment", "spec": {"newField": 1, "template": {"spec": {}}}}`
+	workload, err := NewWorkloadFromObject(protocol.GroupVersionKind{Kind: "Deployment"}, []byte(object))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err = workload.SetPodSpec(corev1.PodSpec{ServiceAccountName: "nginx"}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err = workload.SetPodTemplateMetadata(metav1.ObjectMeta{Labels: map[string]string{"app": "nginx"}}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `{"kind": "Deployment", "spec": {"newField": 1, "template": {"spec": {"containers":null,"serviceAccountName":"nginx"},"metadata":{"labels":{"app":"nginx"}}}}}`
+	if string(workload.Object()) != expected {
+		t.Fatalf("Unexpected object: %s", workload.Object())
+	}
+}
+
+func TestUnsupportedWorkloadKind(t *testing.T) {
+	_, err := NewWorkloadFromObject(protocol.GroupVersionKind{Kind: "Service"}, []byte(`{}`))
+
+	var unsupportedKindErr *UnsupportedWorkloadKindError
+	if !errors.As(err, &unsupportedKindErr) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if unsupportedKindErr.Kind != "Service" {
+		t.Fatalf("Unexpected kind: %s", unsupportedKindErr.Kind)
+	}
+
+	expectedMessage := "object of kind Service should be one of these kinds: apps/v1 Deployment, apps/v1 ReplicaSet, apps/v1 StatefulSet, " +
+		"apps/v1 DaemonSet, v1 ReplicationController, batch/v1 Job, batch/v1 CronJob, v1 Pod"
+	if err.Error() != expectedMessage {
+		t.Fatalf("Unexpected error message: %s", err.Error())
+	}
+}
+
+func TestRegisterWorkloadKind(t *testing.T) {
+	restoreWorkloadKinds(t)
+
+	err := RegisterWorkloadKind(WorkloadKind{
+		Group:           "serving.knative.dev",
+		Kind:            "Service",
+		PodTemplatePath: rawjson.MustParsePath("spec.template"),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err = RegisterWorkloadKind(WorkloadKind{}); err == nil {
+		t.Fatalf("Empty kind registered")
+	}
+
+	knativeService := protocol.GroupVersionKind{Group: "serving.knative.dev", Version: "v1", Kind: "Service"}
+	workload, err := NewWorkloadFromObject(knativeService, []byte(`{"spec": {"template": {"spec": {"serviceAccountName": "knative"}}}}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	podSpec, err := workload.PodSpec()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if podSpec.ServiceAccountName != "knative" {
+		t.Fatalf("Unexpected PodSpec: %+v", podSpec)
+	}
+
+	coreService := protocol.GroupVersionKind{Version: "v1", Kind: "Service"}
+	_, err = NewWorkloadFromObject(coreService, []byte(`{}`))
+	expectedMessage := "object of kind v1 Service should be one of these kinds: apps/v1 Deployment, apps/v1 ReplicaSet, apps/v1 StatefulSet, " +
+		"apps/v1 DaemonSet, v1 ReplicationController, batch/v1 Job, batch/v1 CronJob, v1 Pod, serving.knative.dev/* Service"
+	if err == nil || err.Error() != expectedMessage {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	otherService := protocol.GroupVersionKind{Group: "example.com", Version: "v1alpha1", Kind: "Service"}
+	_, err = NewWorkloadFromObject(otherService, []byte(`{}`))
+	var unsupportedKindErr *UnsupportedWorkloadKindError
+	if !errors.As(err, &unsupportedKindErr) || unsupportedKindErr.GroupVersionKind != otherService ||
+		!strings.HasPrefix(err.Error(), "object of kind example.com/v1alpha1 Service should be one of these kinds: ") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestMutatePodSpecFromRequestWithRegisteredKind(t *testing.T) {
+	restoreWorkloadKinds(t)
+
+	err := RegisterWorkloadKind(WorkloadKind{
+		Group:           "argoproj.io",
+		Kind:            "Rollout",
+		PodTemplatePath: rawjson.MustParsePath("spec.template"),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	validationRequest := protocol.ValidationRequest{
+		Request: protocol.KubernetesAdmissionRequest{
+			Kind:   protocol.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+			Object: json.RawMessage(`{"spec": {"strategy": {"canary": {}}, "template": {"spec": {}}}}`),
+		},
+	}
+
+	rawResponse, err := MutatePodSpecFromRequest(validationRequest, corev1.PodSpec{AutomountServiceAccountToken: true})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `{"accepted":true,"mutated_object":{"spec":{"strategy":{"canary":{}},"template":{"spec":{"automountServiceAccountToken":true,"containers":null}}}}}`
+	if string(rawResponse) != expected {
+		t.Fatalf("Unexpected response: %s", rawResponse)
+	}
+}